@@ -0,0 +1,75 @@
+package stringset
+
+// A ChurnTracker measures changes in membership across a sequence of set
+// snapshots.  The zero value is ready for use, and computes its rate over all
+// the snapshots it observes; use NewChurnTracker to compute the rate over a
+// sliding window of recent snapshots instead.
+type ChurnTracker struct {
+	last    Set
+	n       int   // number of snapshots observed
+	added   int   // additions between the two most recent snapshots
+	removed int   // removals between the two most recent snapshots
+	total   int   // additions plus removals over the window
+	deltas  []int // changes per snapshot in the window, if windowed
+	next    int   // index in deltas of the oldest change
+}
+
+// NewChurnTracker returns a ChurnTracker whose Rate is computed over the
+// changes between the window+1 most recent snapshots, so that a recent burst
+// of changes is not diluted by a long quiet history.  If window ≤ 0, the rate
+// is computed over all snapshots, as for the zero value.
+func NewChurnTracker(window int) *ChurnTracker {
+	if window <= 0 {
+		return new(ChurnTracker)
+	}
+	return &ChurnTracker{deltas: make([]int, 0, window)}
+}
+
+// Observe records s as the next snapshot in the sequence.  The tracker keeps
+// a copy of s, so the caller may modify s afterward.
+func (c *ChurnTracker) Observe(s Set) {
+	if c.n > 0 {
+		c.added = len(s.Diff(c.last))
+		c.removed = len(c.last.Diff(s))
+		c.record(c.added + c.removed)
+	}
+	c.last = s.Clone()
+	c.n++
+}
+
+// record adds a change count to the window, evicting the oldest count if the
+// window is full.
+func (c *ChurnTracker) record(delta int) {
+	c.total += delta
+	switch {
+	case cap(c.deltas) == 0:
+		// Not windowed; keep the total over all snapshots.
+	case len(c.deltas) < cap(c.deltas):
+		c.deltas = append(c.deltas, delta)
+	default:
+		c.total -= c.deltas[c.next]
+		c.deltas[c.next] = delta
+		c.next = (c.next + 1) % len(c.deltas)
+	}
+}
+
+// Added returns the number of elements added between the two most recent
+// snapshots, or 0 if fewer than two snapshots have been observed.
+func (c *ChurnTracker) Added() int { return c.added }
+
+// Removed returns the number of elements removed between the two most recent
+// snapshots, or 0 if fewer than two snapshots have been observed.
+func (c *ChurnTracker) Removed() int { return c.removed }
+
+// Rate returns the mean number of changes (additions plus removals) between
+// consecutive snapshots in the window, or over all the snapshots observed if
+// the tracker is not windowed.  It returns 0 if fewer than two snapshots have
+// been observed.
+func (c *ChurnTracker) Rate() float64 {
+	if c.n < 2 {
+		return 0
+	} else if cap(c.deltas) != 0 {
+		return float64(c.total) / float64(len(c.deltas))
+	}
+	return float64(c.total) / float64(c.n-1)
+}
@@ -0,0 +1,98 @@
+package stringset_test
+
+import (
+	"math"
+	"testing"
+
+	"bitbucket.org/creachadair/stringset"
+)
+
+func TestChurnTracker(t *testing.T) {
+	var c stringset.ChurnTracker
+	if a, r, rate := c.Added(), c.Removed(), c.Rate(); a != 0 || r != 0 || rate != 0 {
+		t.Errorf("Empty tracker: got (%d, %d, %v), want (0, 0, 0)", a, r, rate)
+	}
+
+	tests := []struct {
+		snap           stringset.Set
+		added, removed int
+		rate           float64
+	}{
+		{testSet(0, 1, 2), 0, 0, 0},
+		{testSet(0, 1, 2), 0, 0, 0},           // no change
+		{testSet(0, 1, 2, 3, 4), 2, 0, 1},     // +3 +4
+		{testSet(1, 3), 0, 3, 5.0 / 3},        // -0 -2 -4
+		{nil, 0, 2, 7.0 / 4},                  // -1 -3
+		{testSet(5, 6, 7, 8), 4, 0, 11.0 / 5}, // +5 +6 +7 +8
+		{testSet(5, 6, 9), 1, 2, 14.0 / 6},    // -7 -8 +9
+	}
+	for i, test := range tests {
+		c.Observe(test.snap)
+		if got := c.Added(); got != test.added {
+			t.Errorf("Snapshot %d %v: Added() = %d, want %d", i, test.snap, got, test.added)
+		}
+		if got := c.Removed(); got != test.removed {
+			t.Errorf("Snapshot %d %v: Removed() = %d, want %d", i, test.snap, got, test.removed)
+		}
+		if got := c.Rate(); got != test.rate {
+			t.Errorf("Snapshot %d %v: Rate() = %v, want %v", i, test.snap, got, test.rate)
+		}
+	}
+}
+
+func TestChurnTrackerCopies(t *testing.T) {
+	var c stringset.ChurnTracker
+	s := testSet(0, 1)
+	c.Observe(s)
+	s.Add(testValues[2]) // should not affect the recorded snapshot
+	c.Observe(s)
+	if got := c.Added(); got != 1 {
+		t.Errorf("Added() after modifying snapshot: got %d, want 1", got)
+	}
+}
+
+func TestChurnTrackerWindow(t *testing.T) {
+	c := stringset.NewChurnTracker(3)
+	tests := []struct {
+		snap stringset.Set
+		rate float64
+	}{
+		{testSet(0, 1, 2), 0},
+		{testSet(0, 1, 2, 3), 1},          // +3
+		{testSet(0, 1, 2), 1},             // -3
+		{testSet(0, 1, 2), 2.0 / 3},       // no change
+		{testSet(0, 1, 2), 1.0 / 3},       // no change; +3 leaves the window
+		{testSet(0, 1, 2), 0},             // no change; -3 leaves the window
+		{testSet(5, 6, 7, 8), 7.0 / 3},    // -0 -1 -2 +5 +6 +7 +8
+		{testSet(5, 6, 7, 8, 9), 8.0 / 3}, // +9
+	}
+	for i, test := range tests {
+		c.Observe(test.snap)
+		if got := c.Rate(); math.Abs(got-test.rate) > 1e-9 {
+			t.Errorf("Snapshot %d %v: Rate() = %v, want %v", i, test.snap, got, test.rate)
+		}
+	}
+}
+
+func TestChurnTrackerBurst(t *testing.T) {
+	// After a long quiet period, a burst of changes raises the windowed rate
+	// much more than the rate over the whole history.
+	all := new(stringset.ChurnTracker)
+	win := stringset.NewChurnTracker(5)
+	quiet := testSet(0, 1, 2)
+	for i := 0; i < 100; i++ {
+		all.Observe(quiet)
+		win.Observe(quiet)
+	}
+	for i := 0; i < 3; i++ {
+		flap := quiet
+		if i%2 == 0 {
+			flap = testSet(5, 6, 7, 8, 9)
+		}
+		all.Observe(flap)
+		win.Observe(flap)
+	}
+	if a, w := all.Rate(), win.Rate(); w < 3 || a > 0.5 {
+		t.Errorf("Rate after burst: got whole history %v, window %v; want < 0.5 and ≥ 3", a, w)
+	}
+}
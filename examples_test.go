@@ -2,6 +2,7 @@ package stringset_test
 
 import (
 	"fmt"
+	"log"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -37,12 +38,36 @@ func ExampleSet_Add() {
 }
 
 func ExampleSet_Select() {
+	s := stringset.New("a", "bb", "ccc", "dddd").Select(func(s string) bool {
+		return len(s)%2 == 0
+	})
+	fmt.Println(s)
+	// Output: {"bb", "dddd"}
+}
+
+func ExampleSet_SelectRegexp() {
 	re := regexp.MustCompile(`[a-z]\d+`)
-	s := stringset.New("a", "b15", "c9", "q").Select(re.MatchString)
+	s := stringset.New("a", "b15", "c9", "q").SelectRegexp(re)
 	fmt.Println(s)
 	// Output: {"b15", "c9"}
 }
 
+func ExampleSet_DiscardRegexp() {
+	s := stringset.New("tmp/a", "src/b", "tmp/c", "src/d")
+	n := s.DiscardRegexp(regexp.MustCompile(`^tmp/`))
+	fmt.Println(n, s)
+	// Output: 2 {"src/b", "src/d"}
+}
+
+func ExampleSet_SelectGlob() {
+	s, err := stringset.New("main.go", "README", "util.go", "util_test.cc").SelectGlob("*.go")
+	if err != nil {
+		log.Fatalf("SelectGlob: %v", err)
+	}
+	fmt.Println(s)
+	// Output: {"main.go", "util.go"}
+}
+
 func ExampleSet_Choose() {
 	s := stringset.New("a", "ab", "abc", "abcd")
 	long, ok := s.Choose(func(c string) bool {
@@ -0,0 +1,5 @@
+package stringset
+
+// ContainsReflect exposes the reflection-based path of Contains, so that tests
+// can check that it agrees with the fast paths for specific map types.
+var ContainsReflect = containsReflect
@@ -0,0 +1,104 @@
+package stringset
+
+// A Term is an operand of a set expression, either a Set or an *Expr.
+type Term interface {
+	// has reports whether the term contains elt.
+	has(elt string) bool
+
+	// scan calls f for each element that may belong to the term, possibly
+	// more than once, and possibly for elements that do not belong to it.
+	scan(f func(string))
+
+	// size returns an upper bound on the number of elements in the term.
+	size() int
+}
+
+func (s Set) has(elt string) bool { return s.Has(elt) }
+func (s Set) scan(f func(string)) { s.Each(f) }
+func (s Set) size() int           { return len(s) }
+
+// An Expr is a set expression whose value is not computed until its Eval
+// method is called.  Evaluating an expression visits the elements of its
+// operands directly, without constructing intermediate sets for any of its
+// subexpressions. For example:
+//
+//	Union(a, b).Diff(Intersect(c, d)).Eval()
+//
+// computes (a ∪ b) \ (c ∩ d) without allocating anything except the result.
+//
+// An Expr refers to its operands, so changes to those operands before Eval is
+// called are reflected in the result.
+type Expr struct {
+	op   byte // one of '|', '&', '-'
+	l, r Term
+}
+
+// Union returns an expression for the union a ∪ b.
+func Union(a, b Term) *Expr { return &Expr{op: '|', l: a, r: b} }
+
+// Intersect returns an expression for the intersection a ∩ b.
+func Intersect(a, b Term) *Expr { return &Expr{op: '&', l: a, r: b} }
+
+// Diff returns an expression for the set difference a \ b.
+func Diff(a, b Term) *Expr { return &Expr{op: '-', l: a, r: b} }
+
+// Union returns an expression for the union e ∪ t.
+func (e *Expr) Union(t Term) *Expr { return Union(e, t) }
+
+// Intersect returns an expression for the intersection e ∩ t.
+func (e *Expr) Intersect(t Term) *Expr { return Intersect(e, t) }
+
+// Diff returns an expression for the set difference e \ t.
+func (e *Expr) Diff(t Term) *Expr { return Diff(e, t) }
+
+// Eval computes the value of e.  As with the corresponding Set methods, the
+// result is nil if e denotes the empty set.
+func (e *Expr) Eval() Set {
+	var out Set
+	e.scan(func(elt string) {
+		if _, ok := out[elt]; !ok && e.has(elt) {
+			out.Add(elt)
+		}
+	})
+	return out
+}
+
+func (e *Expr) has(elt string) bool {
+	switch e.op {
+	case '|':
+		return e.l.has(elt) || e.r.has(elt)
+	case '&':
+		return e.l.has(elt) && e.r.has(elt)
+	default:
+		return e.l.has(elt) && !e.r.has(elt)
+	}
+}
+
+func (e *Expr) scan(f func(string)) {
+	switch e.op {
+	case '|':
+		e.l.scan(f)
+		e.r.scan(f)
+	case '&':
+		if e.r.size() < e.l.size() {
+			e.r.scan(f) // Iterate over the smaller operand
+		} else {
+			e.l.scan(f)
+		}
+	default:
+		e.l.scan(f)
+	}
+}
+
+func (e *Expr) size() int {
+	l, r := e.l.size(), e.r.size()
+	switch e.op {
+	case '|':
+		return l + r
+	case '&':
+		if r < l {
+			return r
+		}
+	}
+	return l
+}
@@ -0,0 +1,71 @@
+package stringset_test
+
+import (
+	"testing"
+
+	"bitbucket.org/creachadair/stringset"
+)
+
+func TestExpr(t *testing.T) {
+	nat := stringset.New(testValues[:]...)
+	a := testSet(0, 1, 2, 3, 4)
+	b := testSet(0, 4, 5, 6, 7)
+	c := testSet(3, 4, 8, 9)
+	d := testSet(1, 3, 4, 9)
+	var empty stringset.Set
+
+	tests := []struct {
+		desc string
+		expr *stringset.Expr
+		want stringset.Set
+	}{
+		{"ø ∪ ø", stringset.Union(empty, empty), empty.Union(empty)},
+		{"a ∪ b", stringset.Union(a, b), a.Union(b)},
+		{"a ∩ b", stringset.Intersect(a, b), a.Intersect(b)},
+		{"a \\ b", stringset.Diff(a, b), a.Diff(b)},
+		{"a ∩ ø", stringset.Intersect(a, empty), a.Intersect(empty)},
+		{"ø \\ a", stringset.Diff(empty, a), empty.Diff(a)},
+		{"a \\ nat", stringset.Diff(a, nat), a.Diff(nat)},
+		{"(a ∪ b) \\ (c ∩ d)",
+			stringset.Union(a, b).Diff(stringset.Intersect(c, d)),
+			a.Union(b).Diff(c.Intersect(d)),
+		},
+		{"(a ∩ b) ∪ (c ∩ d)",
+			stringset.Intersect(a, b).Union(stringset.Intersect(c, d)),
+			a.Intersect(b).Union(c.Intersect(d)),
+		},
+		{"((a \\ b) ∪ c) ∩ d",
+			stringset.Diff(a, b).Union(c).Intersect(d),
+			a.Diff(b).Union(c).Intersect(d),
+		},
+		{"nat \\ (a ∪ b ∪ c ∪ d)",
+			stringset.Diff(nat, stringset.Union(a, b).Union(c).Union(d)),
+			nat.Diff(a.Union(b).Union(c).Union(d)),
+		},
+		{"(a ∪ b) ∩ (a ∪ b)",
+			stringset.Intersect(stringset.Union(a, b), stringset.Union(b, a)),
+			a.Union(b),
+		},
+	}
+	for _, test := range tests {
+		got := test.expr.Eval()
+		if !got.Equals(test.want) {
+			t.Errorf("Eval %s: got %v, want %v", test.desc, got, test.want)
+		}
+		if test.want.Empty() && got != nil {
+			t.Errorf("Eval %s: got %#v, want nil", test.desc, got)
+		}
+	}
+}
+
+func TestExprDeferred(t *testing.T) {
+	a := testSet(0, 1)
+	b := testSet(1, 2)
+	e := stringset.Union(a, b)
+
+	// Changes to the operands before evaluation are reflected in the result.
+	a.Add(testValues[5])
+	if got, want := e.Eval(), testSet(0, 1, 2, 5); !got.Equals(want) {
+		t.Errorf("Eval after update: got %v, want %v", got, want)
+	}
+}
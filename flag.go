@@ -0,0 +1,53 @@
+package stringset
+
+import (
+	"errors"
+	"strings"
+)
+
+// SetFlag implements the flag.Value interface for a Set.  Each time the flag
+// is set, its value is split into tokens which are added to *Target, so a
+// flag may be repeated, or given a delimited list of values, or both:
+//
+//	var tags stringset.Set
+//	flag.Var(&stringset.SetFlag{Target: &tags, Sep: ","}, "tags", "Comma-separated tags")
+//
+// If Sep is empty, the value is split on runs of whitespace. Otherwise it is
+// split on Sep, and leading and trailing whitespace is removed from each
+// token. Empty tokens are ignored.  Setting a flag whose Target is nil
+// reports an error.
+type SetFlag struct {
+	Target *Set   // the set to which tokens are added
+	Sep    string // the delimiter between tokens
+}
+
+// String renders the contents of the target set.  It implements part of the
+// flag.Value interface.
+func (f *SetFlag) String() string {
+	if f == nil || f.Target == nil {
+		return Set(nil).String()
+	}
+	return f.Target.String()
+}
+
+// Set adds the tokens of s to the target set, leaving it unchanged if s has no
+// tokens.  It implements part of the flag.Value interface.
+func (f *SetFlag) Set(s string) error {
+	if f.Target == nil {
+		return errors.New("SetFlag has no target set")
+	}
+	var toks []string
+	if f.Sep == "" {
+		toks = strings.Fields(s)
+	} else {
+		for _, tok := range strings.Split(s, f.Sep) {
+			if tok = strings.TrimSpace(tok); tok != "" {
+				toks = append(toks, tok)
+			}
+		}
+	}
+	if len(toks) != 0 {
+		f.Target.Add(toks...)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package stringset_test
+
+import (
+	"flag"
+	"io"
+	"testing"
+
+	"bitbucket.org/creachadair/stringset"
+)
+
+var _ flag.Value = (*stringset.SetFlag)(nil)
+
+func TestSetFlag(t *testing.T) {
+	tests := []struct {
+		sep  string
+		args []string
+		want stringset.Set
+	}{
+		{",", nil, nil},
+		{",", []string{"-tags", "a"}, stringset.New("a")},
+		{",", []string{"-tags", "a,b", "-tags", "c"}, stringset.New("a", "b", "c")},
+		{",", []string{"-tags", " a , b,,a ", "-tags=b,c"}, stringset.New("a", "b", "c")},
+		{",", []string{"-tags", "a b"}, stringset.New("a b")},
+		{"", []string{"-tags", "a  b\tc", "-tags", "d"}, stringset.New("a", "b", "c", "d")},
+		{"", []string{"-tags", "  "}, nil},
+		{"", []string{"-tags", ""}, nil},
+		{",", []string{"-tags", " , ,"}, nil},
+		{",", []string{"-tags", ""}, nil},
+		{":", []string{"-tags", "x:y", "-tags", "x,z"}, stringset.New("x", "y", "x,z")},
+	}
+	for _, test := range tests {
+		var tags stringset.Set
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		fs.Var(&stringset.SetFlag{Target: &tags, Sep: test.sep}, "tags", "Tags to apply")
+		if err := fs.Parse(test.args); err != nil {
+			t.Errorf("Parse %q: unexpected error: %v", test.args, err)
+			continue
+		}
+		if !tags.Equals(test.want) {
+			t.Errorf("Parse %q with sep %q: got %v, want %v", test.args, test.sep, tags, test.want)
+		}
+		if test.want == nil && tags != nil {
+			t.Errorf("Parse %q with sep %q: got %#v, want nil", test.args, test.sep, tags)
+		}
+		if got, want := fs.Lookup("tags").Value.String(), test.want.String(); got != want {
+			t.Errorf("Flag string: got %q, want %q", got, want)
+		}
+	}
+}
+
+func TestSetFlagZero(t *testing.T) {
+	var f stringset.SetFlag
+	if got, want := f.String(), "ø"; got != want {
+		t.Errorf("Zero SetFlag String(): got %q, want %q", got, want)
+	}
+	if err := f.Set("a,b"); err == nil {
+		t.Error("Zero SetFlag Set(a,b): got nil error, want error")
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Var(&f, "tags", "Tags to apply")
+	if err := fs.Parse([]string{"-tags", "a"}); err == nil {
+		t.Error("Parse with zero SetFlag: got nil error, want error")
+	}
+}
@@ -0,0 +1,79 @@
+package stringset
+
+import "sort"
+
+// An IndexedSet is a set of strings that also maintains a sorted index of its
+// elements, for workloads that read the elements in order (for example, to
+// search or paginate them) much more often than they modify the set.  The
+// zero value is ready for use as an empty set.
+//
+// Rather than updating the index on every change, Add and Discard mark the
+// index stale, and the next method that needs the order (Elements, Rank, or
+// At) rebuilds it with a single sort.  A run of updates therefore costs one
+// sort in total, and reads of an unchanged set do not sort at all.
+//
+// Because a read may rebuild the index, an IndexedSet is not safe for
+// concurrent use by multiple goroutines, even if all the accesses are reads,
+// unless the caller synchronizes them.
+type IndexedSet struct {
+	set   Set
+	index []string // sorted elements of set, valid unless dirty
+	dirty bool
+}
+
+// NewIndexed returns a new IndexedSet containing the elements of s.  The
+// result does not share storage with s.
+func NewIndexed(s Set) *IndexedSet {
+	return &IndexedSet{set: s.Clone(), dirty: true}
+}
+
+// Set returns a new Set containing the elements of x.  The result does not
+// share storage with x.
+func (x *IndexedSet) Set() Set { return x.set.Clone() }
+
+// Len returns the number of elements in x.
+func (x *IndexedSet) Len() int { return len(x.set) }
+
+// Contains reports whether x contains (all) the given elements.
+func (x *IndexedSet) Contains(elts ...string) bool { return x.set.Contains(elts...) }
+
+// Add adds the specified elements to x, and reports whether anything was
+// added.
+func (x *IndexedSet) Add(elts ...string) bool {
+	if x.set.Add(elts...) {
+		x.dirty = true
+		return true
+	}
+	return false
+}
+
+// Discard removes the specified elements from x, and reports whether anything
+// was removed.
+func (x *IndexedSet) Discard(elts ...string) bool {
+	if x.set.Discard(elts...) {
+		x.dirty = true
+		return true
+	}
+	return false
+}
+
+// Elements returns the elements of x in sorted order.  The result is the
+// index itself rather than a copy: The caller must not modify it, and it is
+// valid only until the next call to Add or Discard that changes x.
+func (x *IndexedSet) Elements() []string {
+	if x.dirty {
+		x.index = x.set.Elements()
+		x.dirty = false
+	}
+	return x.index
+}
+
+// Rank returns the number of elements of x that are less than elt.  If x
+// contains elt, this is the position of elt in x.Elements().
+func (x *IndexedSet) Rank(elt string) int {
+	return sort.SearchStrings(x.Elements(), elt)
+}
+
+// At returns the element at position i of x.Elements().  It panics if i is
+// out of range.
+func (x *IndexedSet) At(i int) string { return x.Elements()[i] }
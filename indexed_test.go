@@ -0,0 +1,130 @@
+package stringset_test
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"bitbucket.org/creachadair/stringset"
+)
+
+func TestIndexedSet(t *testing.T) {
+	var x stringset.IndexedSet
+	if n := x.Len(); n != 0 {
+		t.Errorf("Empty Len: got %d, want 0", n)
+	}
+	if got := x.Elements(); len(got) != 0 {
+		t.Errorf("Empty Elements: got %q, want empty", got)
+	}
+	if got := x.Rank("x"); got != 0 {
+		t.Errorf("Empty Rank(x): got %d, want 0", got)
+	}
+
+	check := func(want stringset.Set) {
+		t.Helper()
+		elts := want.Elements()
+		if got := x.Elements(); !reflect.DeepEqual(got, elts) {
+			t.Errorf("Elements: got %q, want %q", got, elts)
+		}
+		if got := x.Len(); got != len(elts) {
+			t.Errorf("Len: got %d, want %d", got, len(elts))
+		}
+		if got := x.Set(); !got.Equals(want) {
+			t.Errorf("Set: got %v, want %v", got, want)
+		}
+		for i, elt := range elts {
+			if got := x.At(i); got != elt {
+				t.Errorf("At(%d): got %q, want %q", i, got, elt)
+			}
+			if got := x.Rank(elt); got != i {
+				t.Errorf("Rank(%q): got %d, want %d", elt, got, i)
+			}
+			if !x.Contains(elt) {
+				t.Errorf("Contains(%q): got false, want true", elt)
+			}
+		}
+	}
+
+	if !x.Add(testValues[:5]...) {
+		t.Error("Add: got false, want true")
+	}
+	check(testSet(0, 1, 2, 3, 4))
+
+	if x.Add(testValues[0], testValues[3]) {
+		t.Error("Add existing: got true, want false")
+	}
+	if !x.Discard(testValues[1], testValues[7]) {
+		t.Error("Discard: got false, want true")
+	}
+	if x.Discard(testValues[7]) {
+		t.Error("Discard missing: got true, want false")
+	}
+	x.Add(testValues[9])
+	check(testSet(0, 2, 3, 4, 9))
+
+	if x.Contains(testValues[1]) {
+		t.Errorf("Contains(%q): got true, want false", testValues[1])
+	}
+
+	// Rank reports the insertion position of missing elements.
+	for _, test := range []struct {
+		elt  string
+		want int
+	}{
+		{"", 0}, {"a", 0}, {"f", 1}, {"nine", 2}, {"p", 4}, {"zzz", 5},
+	} {
+		if got := x.Rank(test.elt); got != test.want {
+			t.Errorf("Rank(%q): got %d, want %d", test.elt, got, test.want)
+		}
+	}
+}
+
+func TestIndexedSetConvert(t *testing.T) {
+	s := testSet(1, 3, 5, 7)
+	x := stringset.NewIndexed(s)
+	if got := x.Set(); !got.Equals(s) {
+		t.Errorf("NewIndexed(%v).Set(): got %v", s, got)
+	}
+
+	// The indexed set does not share storage with its input or output.
+	s.Add(testValues[0])
+	out := x.Set()
+	out.Add(testValues[2])
+	if got, want := x.Set(), testSet(1, 3, 5, 7); !got.Equals(want) {
+		t.Errorf("After changes: got %v, want %v", got, want)
+	}
+
+	if got := stringset.NewIndexed(nil); got.Len() != 0 || got.Set() != nil {
+		t.Errorf("NewIndexed(nil): got %v, want empty", got.Set())
+	}
+}
+
+// Benchmark a read-heavy workload: Look up the position of an element and
+// fetch the page following it.
+const indexedPageSize = 20
+
+func BenchmarkIndexedSet(b *testing.B) {
+	s := benchSet(1 << 18)
+	probes := s.Unordered()[:100]
+
+	b.Run("Set", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			elts := s.Elements()
+			pos := sort.SearchStrings(elts, probes[i%len(probes)])
+			for j := pos; j < pos+indexedPageSize && j < len(elts); j++ {
+				_ = elts[j]
+			}
+		}
+	})
+	b.Run("IndexedSet", func(b *testing.B) {
+		x := stringset.NewIndexed(s)
+		x.Elements() // build the index outside the timer
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			pos := x.Rank(probes[i%len(probes)])
+			for j := pos; j < pos+indexedPageSize && j < x.Len(); j++ {
+				_ = x.At(j)
+			}
+		}
+	})
+}
@@ -0,0 +1,71 @@
+package stringset
+
+import (
+	"math/rand"
+	"reflect"
+)
+
+// generateAlphabet is the set of bytes from which Generate draws the elements
+// of random sets.  It is small so that overlap between sets is likely.
+const generateAlphabet = "abcdef"
+
+// generateSize is the maximum number of elements in a set generated by the
+// function returned from GenerateWith, matching the default complexity of
+// values generated by testing/quick.
+const generateSize = 50
+
+// Generate returns a random Set of up to size elements, each a string of one
+// to three bytes drawn from the alphabet "abcdef".  It implements the
+// testing/quick.Generator interface, so that a Set may be used as an argument
+// to a property checked by quick.Check.  Use GenerateWith to choose a
+// different alphabet.
+func (Set) Generate(rand *rand.Rand, size int) reflect.Value {
+	return reflect.ValueOf(generate(rand, size, generateAlphabet))
+}
+
+// GenerateWith returns a function suitable for the Values field of a
+// quick.Config, that fills each argument with a random Set of up to 50
+// elements, each a string of one to three bytes drawn from alphabet.  If
+// alphabet is empty, every generated set is empty.  All the arguments of the
+// property must have type Set.
+func GenerateWith(alphabet string) func([]reflect.Value, *rand.Rand) {
+	return func(args []reflect.Value, rand *rand.Rand) {
+		for i := range args {
+			args[i] = reflect.ValueOf(generate(rand, generateSize, alphabet))
+		}
+	}
+}
+
+// generate returns a random Set of up to size elements, each a string of one
+// to three bytes drawn from alphabet.
+func generate(rand *rand.Rand, size int, alphabet string) Set {
+	if alphabet == "" {
+		return nil
+	}
+	var s Set
+	for n := rand.Intn(size + 1); n > 0; n-- {
+		b := make([]byte, 1+rand.Intn(3))
+		for i := range b {
+			b[i] = alphabet[rand.Intn(len(alphabet))]
+		}
+		s.Add(string(b))
+	}
+	return s
+}
+
+// GenerateFrom returns a function suitable for the Values field of a
+// quick.Config, that fills each argument with a random subset of universe.
+// All the arguments of the property must have type Set.
+func GenerateFrom(universe []string) func([]reflect.Value, *rand.Rand) {
+	return func(args []reflect.Value, rand *rand.Rand) {
+		for i := range args {
+			var s Set
+			for _, elt := range universe {
+				if rand.Intn(2) == 0 {
+					s.Add(elt)
+				}
+			}
+			args[i] = reflect.ValueOf(s)
+		}
+	}
+}
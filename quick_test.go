@@ -0,0 +1,81 @@
+package stringset_test
+
+import (
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"bitbucket.org/creachadair/stringset"
+)
+
+var _ quick.Generator = stringset.Set(nil)
+
+func TestGenerate(t *testing.T) {
+	if err := quick.Check(func(s stringset.Set) bool {
+		for elt := range s {
+			if len(elt) < 1 || len(elt) > 3 {
+				return false
+			}
+		}
+		return true
+	}, nil); err != nil {
+		t.Error(err)
+	}
+
+	universe := testValues[:]
+	u := stringset.New(universe...)
+	if err := quick.Check(func(a, b stringset.Set) bool {
+		return a.IsSubset(u) && b.IsSubset(u)
+	}, &quick.Config{Values: stringset.GenerateFrom(universe)}); err != nil {
+		t.Errorf("GenerateFrom: values outside the universe: %v", err)
+	}
+
+	for _, alphabet := range []string{"xy", "z", ""} {
+		if err := quick.Check(func(a, b stringset.Set) bool {
+			for elt := range a.Union(b) {
+				if len(elt) < 1 || len(elt) > 3 || strings.Trim(elt, alphabet) != "" {
+					return false
+				}
+			}
+			return alphabet != "" || (a == nil && b == nil)
+		}, &quick.Config{Values: stringset.GenerateWith(alphabet)}); err != nil {
+			t.Errorf("GenerateWith(%q): %v", alphabet, err)
+		}
+	}
+}
+
+func TestAlgebraLaws(t *testing.T) {
+	u := stringset.New(testValues[:]...)
+	cfg := &quick.Config{Values: stringset.GenerateFrom(testValues[:])}
+	tests := []struct {
+		desc string
+		prop interface{}
+	}{
+		{"U \\ (A ∪ B) = (U \\ A) ∩ (U \\ B)", func(a, b stringset.Set) bool {
+			return u.Diff(a.Union(b)).Equals(u.Diff(a).Intersect(u.Diff(b)))
+		}},
+		{"U \\ (A ∩ B) = (U \\ A) ∪ (U \\ B)", func(a, b stringset.Set) bool {
+			return u.Diff(a.Intersect(b)).Equals(u.Diff(a).Union(u.Diff(b)))
+		}},
+		{"A ∩ (B ∪ C) = (A ∩ B) ∪ (A ∩ C)", func(a, b, c stringset.Set) bool {
+			return a.Intersect(b.Union(c)).Equals(a.Intersect(b).Union(a.Intersect(c)))
+		}},
+		{"A ∪ (B ∩ C) = (A ∪ B) ∩ (A ∪ C)", func(a, b, c stringset.Set) bool {
+			return a.Union(b.Intersect(c)).Equals(a.Union(b).Intersect(a.Union(c)))
+		}},
+		{"A ∆ B = (A \\ B) ∪ (B \\ A)", func(a, b stringset.Set) bool {
+			return a.SymDiff(b).Equals(a.Diff(b).Union(b.Diff(a)))
+		}},
+		{"A ⊆ A ∪ B", func(a, b stringset.Set) bool {
+			return a.IsSubset(a.Union(b))
+		}},
+	}
+	for _, test := range tests {
+		if err := quick.Check(test.prop, cfg); err != nil {
+			t.Errorf("%s: %v", test.desc, err)
+		}
+		if err := quick.Check(test.prop, nil); err != nil {
+			t.Errorf("%s (unconstrained): %v", test.desc, err)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+//go:build go1.23
+
+package stringset
+
+import "iter"
+
+// AddSeq adds the elements of seq to *s in-place and reports whether anything
+// was added.  If *s == nil, a new set is allocated before seq is consumed, as
+// with Add.  The sequence is consumed exactly once.
+func (s *Set) AddSeq(seq iter.Seq[string]) bool {
+	in := len(*s)
+	if *s == nil {
+		*s = make(Set)
+	}
+	seq(func(elt string) bool {
+		(*s)[elt] = struct{}{}
+		return true
+	})
+	return len(*s) != in
+}
+
+// DiscardSeq removes the elements of seq from s in-place and reports whether
+// anything was removed.  The sequence is consumed exactly once, even if s is
+// empty.
+func (s Set) DiscardSeq(seq iter.Seq[string]) bool {
+	in := len(s)
+	seq(func(elt string) bool {
+		delete(s, elt)
+		return true
+	})
+	return len(s) != in
+}
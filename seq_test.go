@@ -0,0 +1,106 @@
+//go:build go1.23
+
+package stringset_test
+
+import (
+	"iter"
+	"maps"
+	"slices"
+	"testing"
+
+	"bitbucket.org/creachadair/stringset"
+)
+
+// countedSeq returns a sequence of elts, and a pointer to a count of the
+// number of times the sequence has been consumed.
+func countedSeq(elts ...string) (iter.Seq[string], *int) {
+	n := new(int)
+	seq := slices.Values(elts)
+	return func(yield func(string) bool) { *n++; seq(yield) }, n
+}
+
+func TestAddSeq(t *testing.T) {
+	tests := []struct {
+		input stringset.Set
+		elts  []string
+		want  stringset.Set
+		added bool
+	}{
+		{nil, nil, stringset.New(), false},
+		{stringset.New(), nil, stringset.New(), false},
+		{testSet(0, 1), nil, testSet(0, 1), false},
+		{nil, testKeys(0, 1), testSet(0, 1), true},
+		{testSet(0, 1), testKeys(1, 0, 1, 0), testSet(0, 1), false},
+		{testSet(0), testKeys(2, 2, 2, 0, 2, 3, 3), testSet(0, 2, 3), true},
+	}
+	for _, test := range tests {
+		s := test.input.Clone()
+		seq, n := countedSeq(test.elts...)
+		if got := s.AddSeq(seq); got != test.added {
+			t.Errorf("%v.AddSeq(%q): got %v, want %v", test.input, test.elts, got, test.added)
+		}
+		if s == nil {
+			t.Errorf("%v.AddSeq(%q): set is nil, want allocated", test.input, test.elts)
+		} else if !s.Equals(test.want) {
+			t.Errorf("%v.AddSeq(%q): got %v, want %v", test.input, test.elts, s, test.want)
+		}
+		if *n != 1 {
+			t.Errorf("%v.AddSeq(%q): consumed sequence %d times, want 1", test.input, test.elts, *n)
+		}
+	}
+}
+
+func TestAddSeqAllocatesEarly(t *testing.T) {
+	// The set is allocated before the sequence yields its first element.
+	var s stringset.Set
+	s.AddSeq(func(yield func(string) bool) {
+		if s == nil {
+			t.Error("AddSeq: set not allocated before sequence was consumed")
+		}
+		yield(testValues[0])
+	})
+	if !s.Equals(testSet(0)) {
+		t.Errorf("AddSeq: got %v, want %v", s, testSet(0))
+	}
+}
+
+func TestDiscardSeq(t *testing.T) {
+	tests := []struct {
+		input   stringset.Set
+		elts    []string
+		want    stringset.Set
+		removed bool
+	}{
+		{nil, nil, nil, false},
+		{nil, testKeys(0, 1), nil, false},
+		{testSet(0, 1), nil, testSet(0, 1), false},
+		{testSet(0, 1, 2), testKeys(3, 4), testSet(0, 1, 2), false},
+		{testSet(0, 1, 2), testKeys(1, 1, 1, 3, 1), testSet(0, 2), true},
+		{testSet(0, 1, 2), testKeys(2, 0, 1, 0, 2), stringset.New(), true},
+	}
+	for _, test := range tests {
+		s := test.input.Clone()
+		seq, n := countedSeq(test.elts...)
+		if got := s.DiscardSeq(seq); got != test.removed {
+			t.Errorf("%v.DiscardSeq(%q): got %v, want %v", test.input, test.elts, got, test.removed)
+		}
+		if !s.Equals(test.want) {
+			t.Errorf("%v.DiscardSeq(%q): got %v, want %v", test.input, test.elts, s, test.want)
+		}
+		if *n != 1 {
+			t.Errorf("%v.DiscardSeq(%q): consumed sequence %d times, want 1", test.input, test.elts, *n)
+		}
+	}
+}
+
+func TestAddSeqMapKeys(t *testing.T) {
+	m := map[string]int{testValues[3]: 3, testValues[5]: 5}
+	var s stringset.Set
+	s.AddSeq(maps.Keys(m))
+	if want := testSet(3, 5); !s.Equals(want) {
+		t.Errorf("AddSeq(maps.Keys): got %v, want %v", s, want)
+	}
+	if !s.DiscardSeq(maps.Keys(m)) || !s.Empty() {
+		t.Errorf("DiscardSeq(maps.Keys): got %v, want empty", s)
+	}
+}
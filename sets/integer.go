@@ -0,0 +1,76 @@
+package sets
+
+import "math/bits"
+
+// Integer is a constraint satisfied by the integer types.  It is needed only
+// by the functions that pack or encode sets of integers.
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Bitset packs the elements of s into a slice of bits, and returns the slice
+// along with the least element of s, its offset.  Bit i of the result (bit
+// i%64 of word i/64) is set if and only if offset+i is an element of s.  If s
+// is empty, the result is 0, nil.
+//
+// The result has one bit for each integer between the least and greatest
+// elements of s, so it is compact only when s is dense.  Two bitsets with the
+// same offset may be combined with bitwise operations, e.g., the bitwise OR
+// of their words is the bitset of the union.
+func Bitset[T Integer](s Set[T]) (offset T, words []uint64) {
+	lo, ok := Min(s)
+	if !ok {
+		return 0, nil
+	}
+	hi, _ := Max(s)
+	words = make([]uint64, (uint64(hi)-uint64(lo))/64+1)
+	for k := range s {
+		i := uint64(k) - uint64(lo)
+		words[i/64] |= 1 << (i % 64)
+	}
+	return lo, words
+}
+
+// FromBitset returns a Set containing offset+i for each bit i set in words,
+// where bit i is bit i%64 of words[i/64].  It is the inverse of Bitset.
+func FromBitset[T Integer](offset T, words []uint64) Set[T] {
+	var out Set[T]
+	for w, word := range words {
+		for word != 0 {
+			i := bits.TrailingZeros64(word)
+			out.Add(offset + T(64*w+i))
+			word &^= 1 << i
+		}
+	}
+	return out
+}
+
+// DeltaEncode returns the elements of s in increasing order, encoded as the
+// least element followed by the difference between each element and its
+// predecessor.  A set of closely spaced elements thus encodes as a slice of
+// small values, which compresses well.  If s is empty, the result is nil.
+//
+// Differences are computed in the arithmetic of T, so a difference that does
+// not fit in T wraps around; FromDeltas reverses the wrapping, so it recovers
+// s exactly in every case.
+func DeltaEncode[T Integer](s Set[T]) []T {
+	elts := Sorted(s)
+	for i := len(elts) - 1; i > 0; i-- {
+		elts[i] -= elts[i-1]
+	}
+	return elts
+}
+
+// FromDeltas returns the Set of the running sums of deltas, that is, the set
+// whose elements are deltas[0], deltas[0]+deltas[1], and so on.  It is the
+// inverse of DeltaEncode.
+func FromDeltas[T Integer](deltas []T) Set[T] {
+	var out Set[T]
+	var sum T
+	for _, d := range deltas {
+		sum += d
+		out.Add(sum)
+	}
+	return out
+}
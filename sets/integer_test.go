@@ -0,0 +1,116 @@
+package sets_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"bitbucket.org/creachadair/stringset/sets"
+)
+
+func TestBitset(t *testing.T) {
+	tests := []struct {
+		input  sets.Set[int]
+		offset int
+		words  []uint64
+	}{
+		{nil, 0, nil},
+		{sets.New(5), 5, []uint64{1}},
+		{sets.New(3, 4, 6), 3, []uint64{0b1011}},
+		{sets.New(-2, 0, 63, 64), -2, []uint64{1 | 1<<2, 1<<1 | 1<<2}},
+		{sets.New(10, 10+128), 10, []uint64{1, 0, 1}},
+	}
+	for _, test := range tests {
+		offset, words := sets.Bitset(test.input)
+		if offset != test.offset || !reflect.DeepEqual(words, test.words) {
+			t.Errorf("Bitset(%v): got %d, %#x; want %d, %#x", test.input, offset, words, test.offset, test.words)
+		}
+		if got := sets.FromBitset(offset, words); !got.Equals(test.input) {
+			t.Errorf("FromBitset(%d, %#x): got %v, want %v", offset, words, got, test.input)
+		}
+	}
+}
+
+func TestBitsetRange(t *testing.T) {
+	// The span of a small signed type may exceed its maximum value.
+	s8 := sets.New[int8](math.MinInt8, -1, 0, math.MaxInt8)
+	offset, words := sets.Bitset(s8)
+	if offset != math.MinInt8 || len(words) != 4 {
+		t.Errorf("Bitset(%v): got offset %d, %d words; want %d, 4", s8, offset, len(words), math.MinInt8)
+	}
+	if got := sets.FromBitset(offset, words); !got.Equals(s8) {
+		t.Errorf("FromBitset(Bitset(%v)): got %v", s8, got)
+	}
+
+	u := sets.New[uint64](math.MaxUint64-1, math.MaxUint64)
+	if got := sets.FromBitset(sets.Bitset(u)); !got.Equals(u) {
+		t.Errorf("FromBitset(Bitset(%v)): got %v", u, got)
+	}
+}
+
+func TestBitsetOps(t *testing.T) {
+	// Both sets have the same least element, so their bitsets align.
+	a := sets.New(0, 1, 2, 3, 70, 100)
+	b := sets.New(0, 3, 4, 100, 101)
+	aoff, aw := sets.Bitset(a)
+	boff, bw := sets.Bitset(b)
+	if aoff != boff || len(aw) != len(bw) {
+		t.Fatalf("Bitsets do not align: offsets %d, %d; lengths %d, %d", aoff, boff, len(aw), len(bw))
+	}
+	apply := func(op func(x, y uint64) uint64) sets.Set[int] {
+		out := make([]uint64, len(aw))
+		for i := range out {
+			out[i] = op(aw[i], bw[i])
+		}
+		return sets.FromBitset(aoff, out)
+	}
+	tests := []struct {
+		desc      string
+		got, want sets.Set[int]
+	}{
+		{"or", apply(func(x, y uint64) uint64 { return x | y }), a.Union(b)},
+		{"and", apply(func(x, y uint64) uint64 { return x & y }), a.Intersect(b)},
+		{"and not", apply(func(x, y uint64) uint64 { return x &^ y }), a.Diff(b)},
+		{"xor", apply(func(x, y uint64) uint64 { return x ^ y }), a.SymDiff(b)},
+	}
+	for _, test := range tests {
+		if !test.got.Equals(test.want) {
+			t.Errorf("Bitwise %s: got %v, want %v", test.desc, test.got, test.want)
+		}
+	}
+}
+
+func TestDeltaEncode(t *testing.T) {
+	tests := []struct {
+		input sets.Set[int]
+		want  []int
+	}{
+		{nil, nil},
+		{sets.New[int](), nil},
+		{sets.New(42), []int{42}},
+		{sets.New(-7), []int{-7}},
+		{sets.New(100, 101, 102, 105), []int{100, 1, 1, 3}},
+		{sets.New(-5, 0, 5, 1000), []int{-5, 5, 5, 995}},
+	}
+	for _, test := range tests {
+		got := sets.DeltaEncode(test.input)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("DeltaEncode(%v): got %v, want %v", test.input, got, test.want)
+		}
+		if dec := sets.FromDeltas(got); !dec.Equals(test.input) {
+			t.Errorf("FromDeltas(%v): got %v, want %v", got, dec, test.input)
+		}
+	}
+}
+
+func TestDeltaEncodeWrap(t *testing.T) {
+	// Differences that overflow the element type still round-trip.
+	s8 := sets.New[int8](math.MinInt8, 0, math.MaxInt8)
+	if got := sets.FromDeltas(sets.DeltaEncode(s8)); !got.Equals(s8) {
+		t.Errorf("FromDeltas(DeltaEncode(%v)): got %v", s8, got)
+	}
+	u := sets.New[uint16](0, 1, math.MaxUint16)
+	if got := sets.FromDeltas(sets.DeltaEncode(u)); !got.Equals(u) {
+		t.Errorf("FromDeltas(DeltaEncode(%v)): got %v", u, got)
+	}
+}
@@ -0,0 +1,66 @@
+package sets
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Ordered is a constraint satisfied by the types that support the < operator.
+// It is needed only by the functions that depend on the order of elements, so
+// that Set itself may be used with any comparable type.
+type Ordered interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64 |
+		~string
+}
+
+// Sorted returns an ordered slice of the elements in s.
+func Sorted[T Ordered](s Set[T]) []T {
+	return s.ElementsFunc(func(a, b T) bool { return a < b })
+}
+
+// Min returns the least element of s.  The second result is false if s is
+// empty.
+func Min[T Ordered](s Set[T]) (T, bool) {
+	var least T
+	ok := false
+	for k := range s {
+		if !ok || k < least {
+			least, ok = k, true
+		}
+	}
+	return least, ok
+}
+
+// Max returns the greatest element of s.  The second result is false if s is
+// empty.
+func Max[T Ordered](s Set[T]) (T, bool) {
+	var greatest T
+	ok := false
+	for k := range s {
+		if !ok || k > greatest {
+			greatest, ok = k, true
+		}
+	}
+	return greatest, ok
+}
+
+// String renders s in standard set notation with its elements in order, e.g.,
+// ø for an empty set, {1, 2, 3} for a nonempty one.  Elements of string type
+// are quoted, so that a Set[string] renders the same way as a stringset.Set.
+func String[T Ordered](s Set[T]) string {
+	if s.Empty() {
+		return "ø"
+	}
+	format := "%v"
+	if reflect.TypeOf(*new(T)).Kind() == reflect.String {
+		format = "%q"
+	}
+	elts := make([]string, len(s))
+	for i, elt := range Sorted(s) {
+		elts[i] = fmt.Sprintf(format, elt)
+	}
+	return "{" + strings.Join(elts, ", ") + "}"
+}
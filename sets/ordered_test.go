@@ -0,0 +1,80 @@
+package sets_test
+
+import (
+	"reflect"
+	"testing"
+
+	"bitbucket.org/creachadair/stringset"
+	"bitbucket.org/creachadair/stringset/sets"
+)
+
+func TestSorted(t *testing.T) {
+	if got := sets.Sorted(sets.New(5, 3, 9, -1, 3)); !reflect.DeepEqual(got, []int{-1, 3, 5, 9}) {
+		t.Errorf("Sorted(ints): got %v, want [-1 3 5 9]", got)
+	}
+	if got := sets.Sorted(sets.New(testValues[:]...)); !reflect.DeepEqual(got, testValues[:]) {
+		t.Errorf("Sorted(strings): got %+q, want %+q", got, testValues)
+	}
+	if got := sets.Sorted(sets.Set[float64](nil)); got != nil {
+		t.Errorf("Sorted(nil): got %v, want nil", got)
+	}
+}
+
+func TestMinMax(t *testing.T) {
+	ints := sets.New(5, 3, 9, -1, 3)
+	if got, ok := sets.Min(ints); !ok || got != -1 {
+		t.Errorf("Min(%v): got %v, %v; want -1, true", ints, got, ok)
+	}
+	if got, ok := sets.Max(ints); !ok || got != 9 {
+		t.Errorf("Max(%v): got %v, %v; want 9, true", ints, got, ok)
+	}
+
+	strs := sets.New(testValues[:]...)
+	if got, ok := sets.Min(strs); !ok || got != testValues[0] {
+		t.Errorf("Min(%v): got %q, %v; want %q, true", strs, got, ok, testValues[0])
+	}
+	if got, ok := sets.Max(strs); !ok || got != testValues[9] {
+		t.Errorf("Max(%v): got %q, %v; want %q, true", strs, got, ok, testValues[9])
+	}
+
+	var empty sets.Set[int]
+	if got, ok := sets.Min(empty); ok {
+		t.Errorf("Min(ø): got %v, want none", got)
+	}
+	if got, ok := sets.Max(empty); ok {
+		t.Errorf("Max(ø): got %v, want none", got)
+	}
+}
+
+type name string
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		got, want string
+	}{
+		{sets.String(sets.Set[int](nil)), "ø"},
+		{sets.String(sets.New[string]()), "ø"},
+		{sets.String(sets.New(3, 1, 2)), "{1, 2, 3}"},
+		{sets.String(sets.New(2.5, -1.0)), "{-1, 2.5}"},
+		{sets.String(sets.New("b", "a", "c")), `{"a", "b", "c"}`},
+		{sets.String(sets.New[name]("y", "x")), `{"x", "y"}`},
+
+		// Set[string] renders the same as stringset.Set.
+		{sets.String(sets.New(testValues[:]...)), stringset.New(testValues[:]...).String()},
+	}
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("String: got %s, want %s", test.got, test.want)
+		}
+	}
+}
+
+// Sets of a non-ordered type, such as point (see TestComparable), support
+// the core API but not Sorted, Min, Max, or String, which require Ordered.
+func TestUnorderedSubset(t *testing.T) {
+	s := sets.New(point{1, 2}, point{0, 0})
+	got := s.ElementsFunc(func(a, b point) bool { return a.X < b.X })
+	if want := []point{{0, 0}, {1, 2}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ElementsFunc(by X): got %v, want %v", got, want)
+	}
+}
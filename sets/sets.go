@@ -0,0 +1,368 @@
+// Package sets implements a lightweight (finite) set of comparable values
+// based on Go's built-in map.  It mirrors the API of the stringset package,
+// for any comparable element type.
+//
+// A nil Set is ready for use as an empty set.  The basic set methods (Diff,
+// Intersect, Union, IsSubset, Map, Choose, Partition) do not mutate their
+// arguments.  There are also mutating operations (Add, Discard, Pop, Remove,
+// Update) that modify their receiver in-place.
+//
+// Since a comparable type need not be ordered, methods that depend on the
+// order of elements take an ordering function, as in ElementsFunc.
+//
+// A Set can also be traversed and modified using the normal map operations.
+// Being a map, a Set is not safe for concurrent access by multiple goroutines
+// unless all the concurrent accesses are reads.
+package sets
+
+import (
+	"fmt"
+	"sort"
+)
+
+// A Set represents a set of values of type T.  A nil Set is a valid
+// representation of an empty set.
+type Set[T comparable] map[T]struct{}
+
+// New returns a new set containing exactly the specified elements.
+// Returns a non-nil empty Set if no elements are specified.
+func New[T comparable](elts ...T) Set[T] {
+	set := make(Set[T], len(elts))
+	for _, elt := range elts {
+		set[elt] = struct{}{}
+	}
+	return set
+}
+
+// NewSize returns a new empty set pre-sized to hold at least n elements.
+// This is equivalent to make(Set[T], n) and will panic if n < 0.
+func NewSize[T comparable](n int) Set[T] { return make(Set[T], n) }
+
+// Len returns the number of elements in s.
+func (s Set[T]) Len() int { return len(s) }
+
+// ElementsFunc returns a slice of the elements in s, ordered by less.  The
+// sort is not stable, so elements that are equal under less may appear in
+// any order.
+func (s Set[T]) ElementsFunc(less func(a, b T) bool) []T {
+	elts := s.Unordered()
+	sort.Slice(elts, func(i, j int) bool { return less(elts[i], elts[j]) })
+	return elts
+}
+
+// ElementsStable returns a slice of the elements in s, ordered by less.
+// Unlike ElementsFunc, elements that are equal under less are ordered by
+// their Go-syntax representation (as formatted by "%#v"), so the result is
+// the same for every call on the same set, even if less is only a partial
+// order.
+func (s Set[T]) ElementsStable(less func(a, b T) bool) []T {
+	elts := s.Unordered()
+	keys := make([]string, len(elts))
+	for i, elt := range elts {
+		keys[i] = fmt.Sprintf("%#v", elt)
+	}
+	sort.Sort(stableSorter[T]{elts, keys, less})
+	return elts
+}
+
+// stableSorter implements sort.Interface for ElementsStable, ordering by less
+// and then by key.
+type stableSorter[T any] struct {
+	elts []T
+	keys []string
+	less func(a, b T) bool
+}
+
+func (s stableSorter[T]) Len() int { return len(s.elts) }
+func (s stableSorter[T]) Less(i, j int) bool {
+	if s.less(s.elts[i], s.elts[j]) {
+		return true
+	} else if s.less(s.elts[j], s.elts[i]) {
+		return false
+	}
+	return s.keys[i] < s.keys[j]
+}
+func (s stableSorter[T]) Swap(i, j int) {
+	s.elts[i], s.elts[j] = s.elts[j], s.elts[i]
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
+
+// Unordered returns an unordered slice of the elements in s.
+func (s Set[T]) Unordered() []T {
+	if len(s) == 0 {
+		return nil
+	}
+	elts := make([]T, 0, len(s))
+	for elt := range s {
+		elts = append(elts, elt)
+	}
+	return elts
+}
+
+// Clone returns a new Set distinct from s, containing the same elements.
+func (s Set[T]) Clone() Set[T] {
+	var c Set[T]
+	c.Update(s)
+	return c
+}
+
+// Has reports whether s contains elt.
+func (s Set[T]) Has(elt T) bool {
+	_, ok := s[elt]
+	return ok
+}
+
+// ContainsAny reports whether s contains one or more of the given elements.
+// It is equivalent in meaning to
+//
+//	s.Intersects(sets.New(elts...))
+//
+// but does not construct an intermediate set.
+func (s Set[T]) ContainsAny(elts ...T) bool {
+	for _, key := range elts {
+		if _, ok := s[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains reports whether s contains (all) the given elements.
+// It is equivalent in meaning to
+//
+//	New(elts...).IsSubset(s)
+//
+// but does not construct an intermediate set.
+func (s Set[T]) Contains(elts ...T) bool {
+	for _, elt := range elts {
+		if _, ok := s[elt]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// IsSubset reports whether s is a subset of s2, s ⊆ s2.
+func (s Set[T]) IsSubset(s2 Set[T]) bool {
+	if s.Empty() {
+		return true
+	} else if len(s) > len(s2) {
+		return false
+	}
+	for k := range s {
+		if _, ok := s2[k]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Equals reports whether s is equal to s2, having exactly the same elements.
+func (s Set[T]) Equals(s2 Set[T]) bool { return len(s) == len(s2) && s.IsSubset(s2) }
+
+// Empty reports whether s is empty.
+func (s Set[T]) Empty() bool { return len(s) == 0 }
+
+// Intersects reports whether the intersection s ∩ s2 is non-empty, without
+// explicitly constructing the intersection.
+func (s Set[T]) Intersects(s2 Set[T]) bool {
+	a, b := s, s2
+	if len(b) < len(a) {
+		a, b = b, a // Iterate over the smaller set
+	}
+	for k := range a {
+		if _, ok := b[k]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Union constructs the union s ∪ s2.
+func (s Set[T]) Union(s2 Set[T]) Set[T] {
+	if s.Empty() {
+		return s2
+	} else if s2.Empty() {
+		return s
+	}
+	set := make(Set[T])
+	for k := range s {
+		set[k] = struct{}{}
+	}
+	for k := range s2 {
+		set[k] = struct{}{}
+	}
+	return set
+}
+
+// Intersect constructs the intersection s ∩ s2.
+func (s Set[T]) Intersect(s2 Set[T]) Set[T] {
+	if s.Empty() || s2.Empty() {
+		return nil
+	}
+	set := make(Set[T])
+	for k := range s {
+		if _, ok := s2[k]; ok {
+			set[k] = struct{}{}
+		}
+	}
+	if len(set) == 0 {
+		return nil
+	}
+	return set
+}
+
+// Diff constructs the set difference s \ s2.
+func (s Set[T]) Diff(s2 Set[T]) Set[T] {
+	if s.Empty() || s2.Empty() {
+		return s
+	}
+	set := make(Set[T])
+	for k := range s {
+		if _, ok := s2[k]; !ok {
+			set[k] = struct{}{}
+		}
+	}
+	if len(set) == 0 {
+		return nil
+	}
+	return set
+}
+
+// SymDiff constructs the symmetric difference s ∆ s2.
+// It is equivalent in meaning to (s ∪ s2) \ (s ∩ s2).
+func (s Set[T]) SymDiff(s2 Set[T]) Set[T] {
+	return s.Union(s2).Diff(s.Intersect(s2))
+}
+
+// Update adds the elements of s2 to *s in-place, and reports whether anything
+// was added.
+// If *s == nil and s2 ≠ ø, a new set is allocated that is a copy of s2.
+func (s *Set[T]) Update(s2 Set[T]) bool {
+	in := len(*s)
+	if *s == nil && len(s2) > 0 {
+		*s = make(Set[T])
+	}
+	for k := range s2 {
+		(*s)[k] = struct{}{}
+	}
+	return len(*s) != in
+}
+
+// Add adds the specified elements to *s in-place and reports whether anything
+// was added.  If *s == nil, a new set equivalent to New(ss...) is stored in *s.
+func (s *Set[T]) Add(ss ...T) bool {
+	in := len(*s)
+	if *s == nil {
+		*s = make(Set[T])
+	}
+	for _, key := range ss {
+		(*s)[key] = struct{}{}
+	}
+	return len(*s) != in
+}
+
+// Remove removes the elements of s2 from s in-place and reports whether
+// anything was removed.
+//
+// Equivalent to s = s.Diff(s2), but does not allocate a new set.
+func (s Set[T]) Remove(s2 Set[T]) bool {
+	in := s.Len()
+	if !s.Empty() {
+		for k := range s2 {
+			delete(s, k)
+		}
+	}
+	return s.Len() != in
+}
+
+// Discard removes the elements of elts from s in-place and reports whether
+// anything was removed.
+//
+// Equivalent to s.Remove(New(elts...)), but does not allocate an intermediate
+// set for ss.
+func (s Set[T]) Discard(elts ...T) bool {
+	in := s.Len()
+	if !s.Empty() {
+		for _, elt := range elts {
+			delete(s, elt)
+		}
+	}
+	return s.Len() != in
+}
+
+// Map returns the Set that results from applying f to each element of s.
+func (s Set[T]) Map(f func(T) T) Set[T] {
+	var out Set[T]
+	for k := range s {
+		out.Add(f(k))
+	}
+	return out
+}
+
+// Each applies f to each element of s.
+func (s Set[T]) Each(f func(T)) {
+	for k := range s {
+		f(k)
+	}
+}
+
+// Filter returns the subset of s for which f returns true.
+func (s Set[T]) Filter(f func(T) bool) Set[T] {
+	var out Set[T]
+	for k := range s {
+		if f(k) {
+			out.Add(k)
+		}
+	}
+	return out
+}
+
+// Partition returns two disjoint sets, yes containing the subset of s for
+// which f returns true and no containing the subset for which f returns false.
+func (s Set[T]) Partition(f func(T) bool) (yes, no Set[T]) {
+	for k := range s {
+		if f(k) {
+			yes.Add(k)
+		} else {
+			no.Add(k)
+		}
+	}
+	return
+}
+
+// Choose returns an element of s for which f returns true, if one exists.  The
+// second result reports whether such an element was found.
+// If f == nil, chooses an arbitrary element of s. The element chosen is not
+// guaranteed to be the same across repeated calls.
+func (s Set[T]) Choose(f func(T) bool) (T, bool) {
+	for k := range s {
+		if f == nil || f(k) {
+			return k, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// Pop removes and returns an element of s for which f returns true, if one
+// exists (essentially Choose + Discard).  The second result reports whether
+// such an element was found.  If f == nil, pops an arbitrary element of s.
+func (s Set[T]) Pop(f func(T) bool) (T, bool) {
+	if v, ok := s.Choose(f); ok {
+		delete(s, v)
+		return v, true
+	}
+	var zero T
+	return zero, false
+}
+
+// Count returns the number of elements of s for which f returns true.
+func (s Set[T]) Count(f func(T) bool) (n int) {
+	for k := range s {
+		if f(k) {
+			n++
+		}
+	}
+	return
+}
@@ -0,0 +1,633 @@
+package sets_test
+
+import (
+	"reflect"
+	"testing"
+
+	"bitbucket.org/creachadair/stringset/sets"
+)
+
+// testValues contains an ordered sequence of ten set keys used for testing.
+// The order of the keys must reflect the expected order of key listings.
+var testValues = [10]string{
+	"eight",
+	"five",
+	"four",
+	"nine",
+	"one",
+	"seven",
+	"six",
+	"ten",
+	"three",
+	"two",
+}
+
+func testKeys(ixs ...int) (keys []string) {
+	for _, i := range ixs {
+		keys = append(keys, testValues[i])
+	}
+	return
+}
+
+func testSet(ixs ...int) sets.Set[string] {
+	return sets.New(testKeys(ixs...)...)
+}
+
+// elements returns the elements of s in sorted order.
+func elements(s sets.Set[string]) []string {
+	return s.ElementsFunc(func(a, b string) bool { return a < b })
+}
+
+func keyPos(key string) int {
+	for i, v := range testValues {
+		if v == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestEmptiness(t *testing.T) {
+	var s sets.Set[string]
+	if !s.Empty() {
+		t.Errorf("nil Set is not reported empty: %v", s)
+	}
+
+	s = sets.New[string]()
+	if !s.Empty() {
+		t.Errorf("Empty Set is not reported empty: %v", s)
+	}
+	if s == nil {
+		t.Error("New() unexpectedly returned nil")
+	}
+
+	if s := testSet(0); s.Empty() {
+		t.Errorf("Nonempty Set is reported empty: %v", s)
+	}
+}
+
+func TestClone(t *testing.T) {
+	a := sets.New(testValues[:]...)
+	b := testSet(1, 8, 5)
+	c := a.Clone()
+	c.Remove(b)
+	if c.Equals(a) {
+		t.Errorf("Unexpected equality: %v == %v", a, c)
+	} else {
+		t.Logf("%v.Clone().Remove(%v) == %v", a, b, c)
+	}
+	c.Update(b)
+	if !c.Equals(a) {
+		t.Errorf("Unexpected inequality: %v != %v", a, c)
+	}
+
+	var s sets.Set[string]
+	if got := s.Clone(); got != nil {
+		t.Errorf("Clone of nil set: got %v, want nil", got)
+	}
+}
+
+func TestUniqueness(t *testing.T) {
+	// Sets should not contain duplicates.  Obviously this is impossible with
+	// the map implementation, but other representations are viable.
+	s := testSet(0, 5, 1, 2, 1, 3, 8, 4, 9, 4, 4, 6, 7, 2, 0, 0, 1, 4, 8, 4, 9)
+	if got, want := s.Len(), len(testValues); got != want {
+		t.Errorf("s.Len(): got %d, want %d [%v]", got, want, s)
+	}
+
+	// Keys should come out sorted.
+	if got := elements(s); !reflect.DeepEqual(got, testValues[:]) {
+		t.Errorf("s.ElementsFunc(less):\n got %+v,\nwant %+v", got, testValues)
+	}
+}
+
+func TestMembership(t *testing.T) {
+	s := testSet(0, 1, 2, 3, 4)
+	for i, v := range testValues {
+		if got, want := s.ContainsAny(v), i < 5; got != want {
+			t.Errorf("s.ContainsAny(%v): got %v, want %v", v, got, want)
+		}
+	}
+
+	// Test non-mutating selection.
+	if got, ok := s.Choose(func(s string) bool {
+		return s == testValues[0]
+	}); !ok {
+		t.Error("Choose(0): missing element")
+	} else {
+		t.Logf("Found %v for element 0", got)
+	}
+	if got, ok := s.Choose(func(string) bool { return false }); ok {
+		t.Errorf(`Choose(impossible): got %v, want ""`, got)
+	}
+	if got, ok := sets.New[string]().Choose(nil); ok {
+		t.Errorf(`Choose(nil): got %v, want ""`, got)
+	}
+
+	// Test mutating selection.
+	if got, ok := s.Pop(func(s string) bool {
+		return s == testValues[1]
+	}); !ok {
+		t.Error("Pop(1): missing element")
+	} else {
+		t.Logf("Found %v for element 1", got)
+	}
+	// A popped item is removed from the set.
+	if len(s) != 4 {
+		t.Errorf("Length after pop: got %d, want %d", len(s), 4)
+	}
+	// Pop of a nonexistent key returns not-found.
+	if got, ok := s.Pop(func(string) bool { return false }); ok {
+		t.Errorf(`Pop(impossible): got %v, want ""`, got)
+	}
+	// Pop from an empty set returns not-found.
+	if got, ok := sets.New[string]().Pop(nil); ok {
+		t.Errorf(`Pop(nil) on empty: got %v, want ""`, got)
+	}
+}
+
+func TestContainsAny(t *testing.T) {
+	set := sets.New(testValues[2:]...)
+	tests := []struct {
+		keys []string
+		want bool
+	}{
+		{nil, false},
+		{[]string{}, false},
+		{testKeys(0), false},
+		{testKeys(1), false},
+		{testKeys(0, 1), false},
+		{testKeys(7), true},
+		{testKeys(8, 3, 4, 9), true},
+		{testKeys(0, 7, 1, 0), true},
+	}
+	t.Logf("Test set: %v", set)
+	for _, test := range tests {
+		got := set.ContainsAny(test.keys...)
+		if got != test.want {
+			t.Errorf("ContainsAny(%+v): got %v, want %v", test.keys, got, test.want)
+		}
+	}
+}
+
+func TestContainsAll(t *testing.T) {
+	set := sets.New(testValues[2:]...)
+	tests := []struct {
+		keys []string
+		want bool
+	}{
+		{nil, true},
+		{[]string{}, true},
+		{testKeys(2, 4, 6), true},
+		{testKeys(1, 3, 5, 7), false},
+		{testKeys(0), false},
+		{testKeys(5, 5, 5), true},
+	}
+	t.Logf("Test set: %v", set)
+	for _, test := range tests {
+		got := set.Contains(test.keys...)
+		if got != test.want {
+			t.Errorf("Contains(%+v): got %v, want %v", test.keys, got, test.want)
+		}
+	}
+}
+
+func TestIsSubset(t *testing.T) {
+	var empty sets.Set[string]
+	key := testSet(0, 2, 6, 7, 9)
+	for _, test := range [][]string{
+		{}, testKeys(2, 6), testKeys(0, 7, 9),
+	} {
+		probe := sets.New(test...)
+		if !probe.IsSubset(key) {
+			t.Errorf("IsSubset %+v ⊂ %+v is false", probe, key)
+		}
+		if !empty.IsSubset(probe) { // ø is a subset of everything, including itself.
+			t.Errorf("IsSubset ø ⊂ %+v is false", probe)
+		}
+	}
+}
+
+func TestNotSubset(t *testing.T) {
+	tests := []struct {
+		probe, key sets.Set[string]
+	}{
+		{testSet(0), sets.New[string]()},
+		{testSet(0), testSet(1)},
+		{testSet(0, 1), testSet(1)},
+		{testSet(0, 2, 1), testSet(0, 2, 3)},
+	}
+	for _, test := range tests {
+		if test.probe.IsSubset(test.key) {
+			t.Errorf("IsSubset %+v ⊂ %+v is true", test.probe, test.key)
+		}
+	}
+}
+
+func TestEquality(t *testing.T) {
+	nat := sets.New(testValues[:]...)
+	odd := testSet(1, 3, 4, 5, 8)
+	tests := []struct {
+		left, right sets.Set[string]
+		eq          bool
+	}{
+		{nil, nil, true},
+		{nat, nat, true},               // Equality with the same value
+		{testSet(0), testSet(0), true}, // Equality with Different values
+		{testSet(0), nil, false},
+		{nat, odd, false},
+		{nil, testSet(0), false},
+		{testSet(0), testSet(1), false},
+
+		// Various set operations...
+		{nat.Intersect(odd), odd, true},
+		{odd, nat.Intersect(odd), true},
+		{odd.Intersect(nat), odd, true},
+		{odd, odd.Intersect(nat), true},
+		{nat.Intersect(nat), nat, true},
+		{nat, nat.Intersect(nat), true},
+		{nat.Union(odd), nat, true},
+		{nat, nat.Union(odd), true},
+		{odd.Diff(nat), odd, false},
+		{odd, odd.Diff(nat), false},
+		{odd.Diff(nat), nil, true},
+		{nil, odd.Diff(nat), true},
+
+		{testSet(0, 1, 2).Diff(testSet(2, 5, 6)), testSet(1).Union(testSet(0)), true},
+	}
+	for _, test := range tests {
+		if got := test.left.Equals(test.right); got != test.eq {
+			t.Errorf("%v.Equals(%v): got %v, want %v", test.left, test.right, got, test.eq)
+		}
+	}
+}
+
+func TestUnion(t *testing.T) {
+	vkeys := testKeys(0, 4)
+	vowels := testSet(4, 0)
+	consonants := testSet(1, 2, 3, 5, 6, 7, 8, 9)
+
+	if got := elements(vowels.Union(nil)); !reflect.DeepEqual(got, vkeys) {
+		t.Errorf("Vowels ∪ ø: got %+v, want %+v", got, vkeys)
+	}
+	if got := elements(sets.New[string]().Union(vowels)); !reflect.DeepEqual(got, vkeys) {
+		t.Errorf("ø ∪ Vowels: got %+v, want %+v", got, vkeys)
+	}
+
+	if got, want := elements(vowels.Union(consonants)), testValues[:]; !reflect.DeepEqual(got, want) {
+		t.Errorf("Vowels ∪ Consonants: got %+v, want %+v", got, want)
+	}
+}
+
+func TestIntersect(t *testing.T) {
+	empty := sets.New[string]()
+	nat := sets.New(testValues[:]...)
+	odd := testSet(1, 3, 5, 7, 9)
+	prime := testSet(2, 3, 5, 7)
+
+	tests := []struct {
+		left, right sets.Set[string]
+		want        []string
+	}{
+		{empty, empty, nil},
+		{empty, nat, nil},
+		{nat, empty, nil},
+		{nat, nat, testValues[:]},
+		{nat, odd, testKeys(1, 3, 5, 7, 9)},
+		{odd, nat, testKeys(1, 3, 5, 7, 9)},
+		{odd, prime, testKeys(3, 5, 7)},
+		{prime, nat, testKeys(2, 3, 5, 7)},
+	}
+	for _, test := range tests {
+		got := elements(test.left.Intersect(test.right))
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v ∩ %v: got %+v, want %+v", test.left, test.right, got, test.want)
+		} else if want, ok := len(test.want) != 0, test.left.Intersects(test.right); ok != want {
+			t.Errorf("%+v.Intersects(%+v): got %v, want %v", test.left, test.right, ok, want)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	empty := sets.New[string]()
+	nat := sets.New(testValues[:]...)
+	odd := testSet(1, 3, 5, 7, 9)
+	prime := testSet(2, 3, 5, 7)
+
+	tests := []struct {
+		left, right sets.Set[string]
+		want        []string
+	}{
+		{empty, empty, nil},
+		{empty, nat, nil},
+		{nat, empty, testValues[:]},
+		{nat, nat, nil},
+		{nat, odd, testKeys(0, 2, 4, 6, 8)},
+		{odd, nat, nil},
+		{odd, prime, testKeys(1, 9)},
+		{prime, nat, nil},
+	}
+	for _, test := range tests {
+		got := elements(test.left.Diff(test.right))
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v \\ %v: got %+q, want %+q", test.left, test.right, got, test.want)
+		}
+	}
+}
+
+func TestSymDiff(t *testing.T) {
+	a := testSet(0, 1, 2, 3, 4)
+	b := testSet(0, 4, 5, 6, 7)
+	c := testSet(3, 4, 8, 9)
+	empty := sets.New[string]()
+
+	tests := []struct {
+		left, right sets.Set[string]
+		want        []string
+	}{
+		{empty, empty, nil},
+		{empty, a, elements(a)},
+		{b, empty, elements(b)},
+		{a, a, nil},
+		{a, b, testKeys(1, 2, 3, 5, 6, 7)},
+		{b, a, testKeys(1, 2, 3, 5, 6, 7)},
+		{a, c, testKeys(0, 1, 2, 8, 9)},
+		{c, a, testKeys(0, 1, 2, 8, 9)},
+		{c, b, testKeys(0, 3, 5, 6, 7, 8, 9)},
+	}
+	for _, test := range tests {
+		got := elements(test.left.SymDiff(test.right))
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v ∆ %v: got %+v, want %+v", test.left, test.right, got, test.want)
+		}
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	tests := []struct {
+		before, update sets.Set[string]
+		want           []string
+		changed        bool
+	}{
+		{nil, nil, nil, false},
+		{nil, testSet(0), testKeys(0), true},
+		{testSet(1), nil, testKeys(1), false},
+		{testSet(2, 3), testSet(4, 4, 3), testKeys(2, 3, 4), true},
+	}
+	for _, test := range tests {
+		ok := test.before.Update(test.update)
+		if got := elements(test.before); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Update %v: got %+v, want %+q", test.before, got, test.want)
+		}
+		if ok != test.changed {
+			t.Errorf("Update %v reported change=%v, want %v", test.before, ok, test.changed)
+		}
+	}
+}
+
+func TestAdd(t *testing.T) {
+	tests := []struct {
+		before       sets.Set[string]
+		update, want []string
+		changed      bool
+	}{
+		{nil, nil, nil, false},
+		{nil, testKeys(0), testKeys(0), true},
+		{testSet(1), nil, testKeys(1), false},
+		{testSet(0, 1), testKeys(2, 2, 1), testKeys(0, 1, 2), true},
+	}
+	for _, test := range tests {
+		ok := test.before.Add(test.update...)
+		if got := elements(test.before); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Add %v: got %+v, want %+v", test.before, got, test.want)
+		}
+		if ok != test.changed {
+			t.Errorf("Add %v reported change=%v, want %v", test.before, ok, test.changed)
+		}
+	}
+}
+
+func TestRemove(t *testing.T) {
+	tests := []struct {
+		before, update sets.Set[string]
+		want           []string
+		changed        bool
+	}{
+		{nil, nil, nil, false},
+		{nil, testSet(0), nil, false},
+		{testSet(5), nil, testKeys(5), false},
+		{testSet(3, 9), testSet(5, 1, 9), testKeys(3), true},
+		{testSet(0, 1, 2), testSet(4, 6), testKeys(0, 1, 2), false},
+	}
+	for _, test := range tests {
+		ok := test.before.Remove(test.update)
+		if got := elements(test.before); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Remove %v: got %+v, want %+v", test.before, got, test.want)
+		}
+		if ok != test.changed {
+			t.Errorf("Remove %v reported change=%v, want %v", test.before, ok, test.changed)
+		}
+	}
+}
+
+func TestDiscard(t *testing.T) {
+	tests := []struct {
+		before       sets.Set[string]
+		update, want []string
+		changed      bool
+	}{
+		{nil, nil, nil, false},
+		{nil, testKeys(0), nil, false},
+		{testSet(1), nil, testKeys(1), false},
+		{testSet(0, 1), testKeys(2, 2, 1), testKeys(0), true},
+		{testSet(0, 1, 2), testKeys(3, 4), testKeys(0, 1, 2), false},
+	}
+	for _, test := range tests {
+		ok := test.before.Discard(test.update...)
+		if got := elements(test.before); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Discard %v: got %+v, want %+v", test.before, got, test.want)
+		}
+		if ok != test.changed {
+			t.Errorf("Discard %v reported change=%v, want %v", test.before, ok, test.changed)
+		}
+	}
+}
+
+func TestMap(t *testing.T) {
+	in := sets.New(testValues[:]...)
+	got := make([]string, len(testValues))
+	out := in.Map(func(s string) string {
+		if p := keyPos(s); p < 0 {
+			t.Errorf("Unknown input key %v", s)
+		} else {
+			got[p] = s
+		}
+		return s
+	})
+	if !reflect.DeepEqual(got, testValues[:]) {
+		t.Errorf("Incomplete mapping:\n got %+v\nwant %+v", got, testValues)
+	}
+	if !out.Equals(in) {
+		t.Errorf("Incorrect mapping:\n got %v\nwant %v", out, in)
+	}
+}
+
+func TestEach(t *testing.T) {
+	in := sets.New(testValues[:]...)
+	saw := make(map[string]int)
+	in.Each(func(name string) {
+		saw[name]++
+	})
+	for want := range in {
+		if saw[want] != 1 {
+			t.Errorf("Saw [%v] %d times, wanted 1", want, saw[want])
+		}
+	}
+	for got, n := range saw {
+		if _, ok := in[got]; !ok {
+			t.Errorf("Saw [%v] %d times, wanted 0", got, n)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	in := sets.New(testValues[:]...)
+	want := testSet(0, 2, 4, 6, 8)
+	if got := in.Filter(func(s string) bool {
+		pos := keyPos(s)
+		return pos >= 0 && pos%2 == 0
+	}); !got.Equals(want) {
+		t.Errorf("%v.Filter(evens): got %v, want %v", in, got, want)
+	}
+	if got := sets.New[string]().Filter(func(string) bool { return true }); !got.Empty() {
+		t.Errorf("%v.Filter(true): got %v, want empty", sets.New[string](), got)
+	}
+	if got := in.Filter(func(string) bool { return false }); !got.Empty() {
+		t.Errorf("%v.Filter(false): got %v, want empty", in, got)
+	}
+}
+
+func TestPartition(t *testing.T) {
+	in := sets.New(testValues[:]...)
+	tests := []struct {
+		in, left, right sets.Set[string]
+		f               func(string) bool
+		desc            string
+	}{
+		{testSet(0, 1), testSet(0, 1), nil,
+			func(string) bool { return true },
+			"all true",
+		},
+		{testSet(0, 1), nil, testSet(0, 1),
+			func(string) bool { return false },
+			"all false",
+		},
+		{in,
+			testSet(0, 1, 2, 3, 4),
+			testSet(5, 6, 7, 8, 9),
+			func(s string) bool { return keyPos(s) < 5 },
+			"pos(s) < 5",
+		},
+		{in,
+			testSet(1, 3, 5, 7, 9), // odd
+			testSet(0, 2, 4, 6, 8), // even
+			func(s string) bool { return keyPos(s)%2 == 1 },
+			"odd/even",
+		},
+	}
+	for _, test := range tests {
+		gotLeft, gotRight := test.in.Partition(test.f)
+		if !gotLeft.Equals(test.left) {
+			t.Errorf("Partition %s left: got %v, want %v", test.desc, gotLeft, test.left)
+		}
+		if !gotRight.Equals(test.right) {
+			t.Errorf("Partition %s right: got %v, want %v", test.desc, gotRight, test.right)
+		}
+		t.Logf("Partition %v %s\n\t left: %v\n\tright: %v", test.in, test.desc, gotLeft, gotRight)
+	}
+}
+
+func TestElementsFunc(t *testing.T) {
+	s := sets.New(3, 1, 4, 1, 5, 9, 2, 6)
+	if got, want := s.ElementsFunc(func(a, b int) bool { return a < b }), []int{1, 2, 3, 4, 5, 6, 9}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ElementsFunc(<): got %v, want %v", got, want)
+	}
+	if got, want := s.ElementsFunc(func(a, b int) bool { return a > b }), []int{9, 6, 5, 4, 3, 2, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ElementsFunc(>): got %v, want %v", got, want)
+	}
+	if got := sets.New[int]().ElementsFunc(func(a, b int) bool { return a < b }); got != nil {
+		t.Errorf("ElementsFunc on empty: got %v, want nil", got)
+	}
+}
+
+func TestCount(t *testing.T) {
+	s := sets.New(testValues[:]...)
+	if got, want := s.Count(func(s string) bool { return len(s) == 3 }), 4; got != want {
+		t.Errorf("Count(len 3): got %d, want %d", got, want)
+	}
+	if got := sets.Set[string](nil).Count(func(string) bool { return true }); got != 0 {
+		t.Errorf("Count on nil: got %d, want 0", got)
+	}
+}
+
+func TestHas(t *testing.T) {
+	s := sets.New(2, 4, 6)
+	for i := 0; i < 8; i++ {
+		if got, want := s.Has(i), i > 0 && i%2 == 0 && i < 8; got != want {
+			t.Errorf("%v.Has(%d): got %v, want %v", s, i, got, want)
+		}
+	}
+}
+
+func TestElementsStable(t *testing.T) {
+	// Order points by X alone, so that points with equal X are tied.
+	byX := func(a, b point) bool { return a.X < b.X }
+	s := sets.New(point{2, 1}, point{1, 9}, point{2, 0}, point{1, -3}, point{0, 5}, point{2, 10})
+	// Ties are broken by the text of each point, so {2, 10} precedes {2, 1}.
+	want := []point{{0, 5}, {1, -3}, {1, 9}, {2, 0}, {2, 10}, {2, 1}}
+	for i := 0; i < 10; i++ {
+		if got := s.ElementsStable(byX); !reflect.DeepEqual(got, want) {
+			t.Fatalf("ElementsStable(byX): got %v, want %v", got, want)
+		}
+	}
+
+	// Strings that tie under a length ordering are ordered by their quoted form.
+	byLen := func(a, b string) bool { return len(a) < len(b) }
+	words := sets.New("ccc", "bb", "aaa", "a", "cc", "b")
+	if got, want := words.ElementsStable(byLen), []string{"a", "b", "bb", "cc", "aaa", "ccc"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ElementsStable(byLen): got %q, want %q", got, want)
+	}
+
+	if got := sets.Set[point](nil).ElementsStable(byX); got != nil {
+		t.Errorf("ElementsStable of nil: got %v, want nil", got)
+	}
+}
+
+// point is a comparable type with no natural ordering.
+type point struct{ X, Y int }
+
+func TestComparable(t *testing.T) {
+	s := sets.New(point{0, 0}, point{1, 2}, point{1, 2}, point{3, 4})
+	if got, want := s.Len(), 3; got != want {
+		t.Errorf("Len: got %d, want %d", got, want)
+	}
+	if !s.Contains(point{1, 2}, point{3, 4}) {
+		t.Errorf("%v.Contains: missing elements", s)
+	}
+
+	flip := s.Map(func(p point) point { return point{p.Y, p.X} })
+	if want := sets.New(point{0, 0}, point{2, 1}, point{4, 3}); !flip.Equals(want) {
+		t.Errorf("Map(flip): got %v, want %v", flip, want)
+	}
+	if got, want := s.Intersect(flip), sets.New(point{0, 0}); !got.Equals(want) {
+		t.Errorf("Intersect: got %v, want %v", got, want)
+	}
+
+	p, ok := s.Pop(func(p point) bool { return p.X > 2 })
+	if !ok || p != (point{3, 4}) {
+		t.Errorf("Pop(X > 2): got %v, %v; want %v, true", p, ok, point{3, 4})
+	}
+	if p, ok := s.Choose(func(p point) bool { return p.X > 2 }); ok {
+		t.Errorf("Choose(X > 2) after Pop: got %v, want none", p)
+	}
+}
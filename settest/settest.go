@@ -0,0 +1,84 @@
+// Package settest provides helpers for checking the contents of a
+// stringset.Set in tests.
+//
+// Each helper reports a failure via t.Errorf, listing the elements that are
+// missing or unexpected rather than the full contents of both sets, and
+// returns whether the check succeeded.  Expected values may be given as any
+// type accepted by stringset.FromKeys, such as a Set, a []string, or a map
+// with string keys.  A value of any other type is reported as a failure.
+package settest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"bitbucket.org/creachadair/stringset"
+)
+
+// Equal checks that got has exactly the elements of want.
+func Equal(t testing.TB, got stringset.Set, want interface{}) bool {
+	t.Helper()
+	ws, ok := keysOf(want)
+	if !ok {
+		t.Errorf("Cannot compare to a value of type %T", want)
+		return false
+	} else if got.Equals(ws) {
+		return true
+	}
+	t.Errorf("Sets differ:%s", describe(ws.Diff(got), got.Diff(ws)))
+	return false
+}
+
+// Subset checks that every element of got is an element of of.
+func Subset(t testing.TB, got stringset.Set, of interface{}) bool {
+	t.Helper()
+	super, ok := keysOf(of)
+	if !ok {
+		t.Errorf("Cannot compare to a value of type %T", of)
+		return false
+	} else if got.IsSubset(super) {
+		return true
+	}
+	t.Errorf("Set is not a subset:%s", describe(nil, got.Diff(super)))
+	return false
+}
+
+// Contains checks that s contains all the given elements.
+func Contains(t testing.TB, s stringset.Set, elts ...string) bool {
+	t.Helper()
+	if s.Contains(elts...) {
+		return true
+	}
+	t.Errorf("Set is missing elements:%s", describe(stringset.New(elts...).Diff(s), nil))
+	return false
+}
+
+// keysOf converts v to a set as stringset.FromKeys does, and reports whether
+// v has a type that FromKeys accepts.  Since FromKeys returns nil both for an
+// empty input and for one it cannot convert, the type must be checked here.
+func keysOf(v interface{}) (stringset.Set, bool) {
+	switch v.(type) {
+	case nil, string, []string, stringset.Set, stringset.Keyer:
+		return stringset.FromKeys(v), true
+	}
+	if t := reflect.TypeOf(v); t.Kind() == reflect.Map && t.Key() == reflect.TypeOf("") {
+		return stringset.FromKeys(v), true
+	}
+	return nil, false
+}
+
+// describe renders the non-empty sets of missing and unexpected elements, one
+// per line.
+func describe(missing, unexpected stringset.Set) string {
+	var sb strings.Builder
+	if !missing.Empty() {
+		sb.WriteString("\n    missing:    ")
+		sb.WriteString(missing.String())
+	}
+	if !unexpected.Empty() {
+		sb.WriteString("\n    unexpected: ")
+		sb.WriteString(unexpected.String())
+	}
+	return sb.String()
+}
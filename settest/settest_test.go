@@ -0,0 +1,125 @@
+package settest_test
+
+import (
+	"fmt"
+	"testing"
+
+	"bitbucket.org/creachadair/stringset"
+	"bitbucket.org/creachadair/stringset/settest"
+)
+
+// mockTB records the failures reported to it.
+type mockTB struct {
+	testing.TB
+	errors []string
+}
+
+func (m *mockTB) Helper() {}
+
+func (m *mockTB) Errorf(msg string, args ...interface{}) {
+	m.errors = append(m.errors, fmt.Sprintf(msg, args...))
+}
+
+// check verifies that a helper returned ok and reported the given failure
+// message, if any.
+func check(t *testing.T, desc string, m *mockTB, ok bool, want string) {
+	t.Helper()
+	if ok != (want == "") {
+		t.Errorf("%s: got ok=%v, want %v", desc, ok, want == "")
+	}
+	var got string
+	if len(m.errors) > 1 {
+		t.Errorf("%s: got %d failures, want at most 1: %q", desc, len(m.errors), m.errors)
+	} else if len(m.errors) == 1 {
+		got = m.errors[0]
+	}
+	if got != want {
+		t.Errorf("%s: got failure %q, want %q", desc, got, want)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	abc := stringset.New("a", "b", "c")
+	tests := []struct {
+		got  stringset.Set
+		want interface{}
+		msg  string
+	}{
+		{nil, nil, ""},
+		{nil, []string{}, ""},
+		{abc, abc, ""},
+		{abc, []string{"c", "b", "a", "a"}, ""},
+		{abc, map[string]int{"a": 1, "b": 2, "c": 3}, ""},
+		{abc, []string{"a", "b"}, "Sets differ:\n    unexpected: {\"c\"}"},
+		{abc, []string{"a", "b", "c", "d"}, "Sets differ:\n    missing:    {\"d\"}"},
+		{abc, stringset.New("b", "x", "y"),
+			"Sets differ:\n    missing:    {\"x\", \"y\"}\n    unexpected: {\"a\", \"c\"}"},
+		{nil, "a", "Sets differ:\n    missing:    {\"a\"}"},
+
+		// Values that cannot be converted to a set are failures.
+		{nil, []int{1, 2, 3}, "Cannot compare to a value of type []int"},
+		{nil, []int(nil), "Cannot compare to a value of type []int"},
+		{nil, 42, "Cannot compare to a value of type int"},
+		{nil, struct{}{}, "Cannot compare to a value of type struct {}"},
+		{nil, map[int]bool{}, "Cannot compare to a value of type map[int]bool"},
+		{nil, map[string]bool{}, ""},
+	}
+	for _, test := range tests {
+		m := new(mockTB)
+		ok := settest.Equal(m, test.got, test.want)
+		check(t, fmt.Sprintf("Equal(%v, %v)", test.got, test.want), m, ok, test.msg)
+	}
+}
+
+func TestSubset(t *testing.T) {
+	abc := stringset.New("a", "b", "c")
+	tests := []struct {
+		got stringset.Set
+		of  interface{}
+		msg string
+	}{
+		{nil, nil, ""},
+		{nil, []string{"a"}, ""},
+		{stringset.New("a", "c"), abc, ""},
+		{abc, []string{"a", "b", "c"}, ""},
+		{abc, map[string]bool{"a": true, "b": false}, "Set is not a subset:\n    unexpected: {\"c\"}"},
+		{abc, nil, "Set is not a subset:\n    unexpected: {\"a\", \"b\", \"c\"}"},
+
+		// Values that cannot be converted to a set are failures.
+		{nil, struct{}{}, "Cannot compare to a value of type struct {}"},
+		{nil, []int{1}, "Cannot compare to a value of type []int"},
+		{abc, 3.5, "Cannot compare to a value of type float64"},
+	}
+	for _, test := range tests {
+		m := new(mockTB)
+		ok := settest.Subset(m, test.got, test.of)
+		check(t, fmt.Sprintf("Subset(%v, %v)", test.got, test.of), m, ok, test.msg)
+	}
+}
+
+func TestContains(t *testing.T) {
+	abc := stringset.New("a", "b", "c")
+	tests := []struct {
+		s    stringset.Set
+		elts []string
+		msg  string
+	}{
+		{nil, nil, ""},
+		{abc, nil, ""},
+		{abc, []string{"a", "c"}, ""},
+		{abc, []string{"a", "x", "y", "x"}, "Set is missing elements:\n    missing:    {\"x\", \"y\"}"},
+		{nil, []string{"a"}, "Set is missing elements:\n    missing:    {\"a\"}"},
+	}
+	for _, test := range tests {
+		m := new(mockTB)
+		ok := settest.Contains(m, test.s, test.elts...)
+		check(t, fmt.Sprintf("Contains(%v, %q)", test.s, test.elts), m, ok, test.msg)
+	}
+}
+
+func TestRealTB(t *testing.T) {
+	s := stringset.New("x", "y")
+	settest.Equal(t, s, []string{"y", "x"})
+	settest.Subset(t, s, []string{"x", "y", "z"})
+	settest.Contains(t, s, "x")
+}
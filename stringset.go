@@ -13,10 +13,28 @@
 package stringset
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"container/heap"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"math/bits"
+	"os"
+	"path/filepath"
 	"reflect"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"unicode/utf8"
 )
 
 // A Set represents a set of string values.  A nil Set is a valid
@@ -29,11 +47,58 @@ func (s Set) String() string {
 	if s.Empty() {
 		return "ø"
 	}
-	elts := make([]string, len(s))
-	for i, elt := range s.Elements() {
-		elts[i] = strconv.Quote(elt)
+	var sb strings.Builder
+	sb.WriteByte('{')
+	writeJoined(&sb, s.Elements(), ", ", true)
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+// StringFunc renders s in the same notation as String, but with the elements
+// ordered by less rather than lexicographically.  If less == nil, StringFunc
+// is equivalent to String.
+func (s Set) StringFunc(less func(a, b string) bool) string {
+	if s.Empty() {
+		return "ø"
+	}
+	var sb strings.Builder
+	sb.WriteByte('{')
+	writeJoined(&sb, s.ElementsFunc(less), ", ", true)
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+// Join returns the elements of s in sorted order, separated by sep.  It
+// returns "" if s is empty.
+func (s Set) Join(sep string) string {
+	var sb strings.Builder
+	writeJoined(&sb, s.Elements(), sep, false)
+	return sb.String()
+}
+
+// JoinQuoted returns the elements of s in sorted order, each quoted as if by
+// strconv.Quote, separated by sep.  It returns "" if s is empty.
+func (s Set) JoinQuoted(sep string) string {
+	var sb strings.Builder
+	writeJoined(&sb, s.Elements(), sep, true)
+	return sb.String()
+}
+
+// writeJoined writes elts to sb in order, separated by sep, and quoted if
+// quote is true.
+func writeJoined(sb *strings.Builder, elts []string, sep string, quote bool) {
+	var buf []byte
+	for i, elt := range elts {
+		if i > 0 {
+			sb.WriteString(sep)
+		}
+		if quote {
+			buf = strconv.AppendQuote(buf[:0], elt)
+			sb.Write(buf)
+		} else {
+			sb.WriteString(elt)
+		}
 	}
-	return "{" + strings.Join(elts, ", ") + "}"
 }
 
 // New returns a new set containing exactly the specified elements.
@@ -60,6 +125,35 @@ func (s Set) Elements() []string {
 	return elts
 }
 
+// ElementsFunc returns a slice of the elements in s, ordered by less.  If
+// less == nil, ElementsFunc is equivalent to Elements.  The sort is not
+// stable, so if less reports neither a < b nor b < a for distinct elements a
+// and b, they may appear in either order.
+func (s Set) ElementsFunc(less func(a, b string) bool) []string {
+	if less == nil {
+		return s.Elements()
+	}
+	elts := s.Unordered()
+	sort.Slice(elts, func(i, j int) bool { return less(elts[i], elts[j]) })
+	return elts
+}
+
+// ElementsBuf returns an ordered slice of the elements in s, reusing the
+// storage of buf if it has sufficient capacity; otherwise a new slice is
+// allocated.  The existing contents of buf are overwritten.
+//
+// The result may share storage with buf, and s does not retain it, so the
+// caller owns both.  This permits a buffer to be recycled, for example via a
+// sync.Pool, once the caller is finished with the result.
+func (s Set) ElementsBuf(buf []string) []string {
+	elts := buf[:0]
+	for elt := range s {
+		elts = append(elts, elt)
+	}
+	sort.Strings(elts)
+	return elts
+}
+
 // Unordered returns an unordered slice of the elements in s.
 func (s Set) Unordered() []string {
 	if len(s) == 0 {
@@ -72,6 +166,25 @@ func (s Set) Unordered() []string {
 	return elts
 }
 
+// StableElements returns a slice of the elements in s in an arbitrary order
+// that is determined by a fixed hash of each element.  Unlike Unordered, the
+// order is reproducible across calls and program runs; unlike Elements, it is
+// not lexicographic and should not be relied upon to have any other meaning.
+func (s Set) StableElements() []string {
+	elts := s.Unordered()
+	keys := make(map[string]uint64, len(elts))
+	for _, elt := range elts {
+		keys[elt] = hash64(elt)
+	}
+	sort.Slice(elts, func(i, j int) bool {
+		if ki, kj := keys[elts[i]], keys[elts[j]]; ki != kj {
+			return ki < kj
+		}
+		return elts[i] < elts[j]
+	})
+	return elts
+}
+
 // Clone returns a new Set distinct from s, containing the same elements.
 func (s Set) Clone() Set {
 	var c Set
@@ -79,6 +192,14 @@ func (s Set) Clone() Set {
 	return c
 }
 
+// Has reports whether s contains elt.  It is equivalent in meaning to
+// s.Contains(elt), but does not construct a variadic argument slice, and is
+// the preferred way to check for a single element.
+func (s Set) Has(elt string) bool {
+	_, ok := s[elt]
+	return ok
+}
+
 // ContainsAny reports whether s contains one or more of the given elements.
 // It is equivalent in meaning to
 //
@@ -270,7 +391,8 @@ func Index(needle string, elts []string) int {
 
 // Contains reports whether v contains s, for v having type Set, []string,
 // map[string]T, or Keyer. It returns false if v's type does not have one of
-// these forms.
+// these forms. Any map type whose key type is string is accepted, including
+// named map types such as a Set type defined by another package.
 func Contains(v interface{}, s string) bool {
 	switch t := v.(type) {
 	case []string:
@@ -279,7 +401,27 @@ func Contains(v interface{}, s string) bool {
 		return t.Contains(s)
 	case Keyer:
 		return Index(s, t.Keys()) >= 0
+
+	// Check common map types directly, without reflection.
+	case map[string]bool:
+		_, ok := t[s]
+		return ok
+	case map[string]int:
+		_, ok := t[s]
+		return ok
+	case map[string]string:
+		_, ok := t[s]
+		return ok
+	case map[string]interface{}:
+		_, ok := t[s]
+		return ok
 	}
+	return containsReflect(v, s)
+}
+
+// containsReflect reports whether v is a map with string keys that contains
+// s, using reflection.
+func containsReflect(v interface{}, s string) bool {
 	if m := reflect.ValueOf(v); m.IsValid() && m.Kind() == reflect.Map && m.Type().Key() == refType {
 		return m.MapIndex(reflect.ValueOf(s)).IsValid()
 	}
@@ -297,7 +439,8 @@ var refType = reflect.TypeOf((*string)(nil)).Elem()
 
 // FromKeys returns a Set of strings from v, which must either be a string,
 // a []string, a map[string]T, or a Keyer. It returns nil if v's type does
-// not have one of these forms.
+// not have one of these forms. As with Contains, named map types whose key
+// type is string are accepted.
 func FromKeys(v interface{}) Set {
 	var result Set
 	switch t := v.(type) {
@@ -308,7 +451,9 @@ func FromKeys(v interface{}) Set {
 			result.Add(key)
 		}
 		return result
-	case map[string]struct{}: // includes Set
+	case Set:
+		return t.Clone()
+	case map[string]struct{}:
 		for key := range t {
 			result.Add(key)
 		}
@@ -339,7 +484,7 @@ func FromIndexed(n int, f func(int) string) Set {
 }
 
 // FromValues returns a Set of the values from v, which has type map[T]string.
-// Returns the empty set if v does not have a type of this form.
+// It returns nil if v does not have a type of this form.
 func FromValues(v interface{}) Set {
 	if t := reflect.TypeOf(v); t == nil || t.Kind() != reflect.Map || t.Elem() != refType {
 		return nil
@@ -397,13 +542,8 @@ func (s Set) Partition(f func(string) bool) (yes, no Set) {
 // If f == nil, chooses an arbitrary element of s. The element chosen is not
 // guaranteed to be the same across repeated calls.
 func (s Set) Choose(f func(string) bool) (string, bool) {
-	if f == nil {
-		for k := range s {
-			return k, true
-		}
-	}
 	for k := range s {
-		if f(k) {
+		if f == nil || f(k) {
 			return k, true
 		}
 	}
@@ -430,3 +570,1456 @@ func (s Set) Count(f func(string) bool) (n int) {
 	}
 	return
 }
+
+// Digest returns a short hexadecimal fingerprint of the contents of s.  The
+// digest is computed from the sorted elements of s, so it is stable across
+// runs and machines, and is suitable for pinning the expected contents of a
+// set in a test.  Distinct sets are very unlikely to have the same digest.
+func (s Set) Digest() string {
+	h := sha256.New()
+	var buf [binary.MaxVarintLen64]byte
+	for _, elt := range s.Elements() {
+		n := binary.PutUvarint(buf[:], uint64(len(elt)))
+		h.Write(buf[:n])
+		io.WriteString(h, elt)
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// PartitionByScore sorts the elements of s in ascending order of score and
+// splits them into the specified number of buckets of roughly equal size, so
+// that bucket 0 holds the lowest-scoring elements and the last bucket holds
+// the highest.  Elements with equal scores are ordered lexicographically.
+// Elements whose score is NaN are ordered after all others, so they fall in
+// the last non-empty bucket.
+//
+// If len(s) is not a multiple of buckets, the first len(s) % buckets buckets
+// each receive one extra element.  If buckets > len(s), the trailing buckets
+// are empty (nil).  If buckets ≤ 0 the result is nil.
+func (s Set) PartitionByScore(score func(string) float64, buckets int) []Set {
+	if buckets <= 0 {
+		return nil
+	}
+	elts := s.Elements()
+	scores := make(map[string]float64, len(elts))
+	for _, elt := range elts {
+		scores[elt] = score(elt)
+	}
+	sort.SliceStable(elts, func(i, j int) bool {
+		a, b := scores[elts[i]], scores[elts[j]]
+		return a < b || (!math.IsNaN(a) && math.IsNaN(b))
+	})
+
+	out := make([]Set, buckets)
+	size, extra := len(elts)/buckets, len(elts)%buckets
+	for i := range out {
+		n := size
+		if i < extra {
+			n++
+		}
+		if n > 0 {
+			out[i] = New(elts[:n]...)
+			elts = elts[n:]
+		}
+	}
+	return out
+}
+
+// IntersectCount returns the number of elements common to all the given sets,
+// without constructing their intersection.  If no sets are given, or any of
+// them is empty, the result is 0.
+func IntersectCount(sets ...Set) int {
+	if len(sets) == 0 {
+		return 0
+	}
+	small := 0
+	for i, s := range sets {
+		if s.Empty() {
+			return 0
+		} else if len(s) < len(sets[small]) {
+			small = i
+		}
+	}
+
+	n := 0
+nextElt:
+	for k := range sets[small] {
+		for i, s := range sets {
+			if i == small {
+				continue
+			} else if _, ok := s[k]; !ok {
+				continue nextElt
+			}
+		}
+		n++
+	}
+	return n
+}
+
+// SymDiffAll constructs the set of elements that belong to an odd number of
+// the given sets.  For two sets, SymDiffAll(s, s2) is equivalent to
+// s.SymDiff(s2).
+func SymDiffAll(sets ...Set) Set {
+	count := make(map[string]int)
+	for _, s := range sets {
+		for k := range s {
+			count[k]++
+		}
+	}
+	var out Set
+	for k, n := range count {
+		if n%2 == 1 {
+			out.Add(k)
+		}
+	}
+	return out
+}
+
+// HasZero reports whether s contains the empty string, the zero value of its
+// element type.  An empty string in a set is often the result of a missing or
+// unset field in the input data.
+func (s Set) HasZero() bool { return s.ContainsAny("") }
+
+// DiscardZero removes the empty string from s in-place, and reports whether
+// it was present.
+func (s Set) DiscardZero() bool { return s.Discard("") }
+
+// OpKind identifies the kind of change described by an Event.
+type OpKind int
+
+// Constants for the supported Event operations.
+const (
+	OpAdd    OpKind = iota + 1 // add Key to the set
+	OpRemove                   // remove Key from the set
+)
+
+// An Event describes a single change to the membership of a Set.
+type Event struct {
+	Op  OpKind
+	Key string
+}
+
+// Apply applies each of the given events to *s in order, and reports the
+// number of elements actually added and removed.  Events that do not change
+// the set, such as adding an element already present, are not counted.
+// Events with an unknown Op are skipped.  If *s == nil and an element is
+// added, a new set is allocated.
+func (s *Set) Apply(events []Event) (added, removed int) {
+	for _, e := range events {
+		switch e.Op {
+		case OpAdd:
+			if s.Add(e.Key) {
+				added++
+			}
+		case OpRemove:
+			if s.Discard(e.Key) {
+				removed++
+			}
+		}
+	}
+	return
+}
+
+// hllPrecision is the number of hash bits used to select a register in
+// EstimateUnion, which uses 1<<hllPrecision registers.
+const hllPrecision = 14
+
+// EstimateUnion returns an estimate of the number of elements in the union of
+// the given sets, without constructing the union.  It uses a HyperLogLog
+// sketch over 16384 single-byte registers, so memory use is constant
+// regardless of the sizes of the inputs.
+//
+// The standard error of the estimate is about 0.8%; in practice the result is
+// almost always within 3% of the exact value.  Small unions are estimated by
+// linear counting, which is more accurate in that range.
+func EstimateUnion(sets ...Set) uint64 {
+	const m = 1 << hllPrecision
+	var reg [m]uint8
+	for _, s := range sets {
+		for k := range s {
+			h := hash64(k)
+			i := h >> (64 - hllPrecision)
+			w := h<<hllPrecision | 1<<(hllPrecision-1)
+			if rho := uint8(bits.LeadingZeros64(w) + 1); rho > reg[i] {
+				reg[i] = rho
+			}
+		}
+	}
+
+	var sum float64
+	var zeros int
+	for _, r := range reg {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+	alpha := 0.7213 / (1 + 1.079/m)
+	est := alpha * m * m / sum
+	if est <= 2.5*m && zeros != 0 {
+		est = m * math.Log(float64(m)/float64(zeros)) // linear counting
+	}
+	return uint64(est + 0.5)
+}
+
+// fnvHash returns the 64-bit FNV-1a hash of s.
+func fnvHash(s string) uint64 {
+	h := fnv.New64a()
+	io.WriteString(h, s)
+	return h.Sum64()
+}
+
+// hash64 returns a well-mixed 64-bit hash of s.
+func hash64(s string) uint64 {
+	// Apply the SplitMix64 finalizer so that all bits depend on the input;
+	// the low-order k bits of an FNV-1a hash depend only on the low-order k
+	// bits of each input byte.
+	z := fnvHash(s)
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// IndicatorVector returns a slice of len(universe) values in which the ith
+// value reports whether s contains universe[i].  Elements of s that do not
+// occur in universe are ignored.
+func (s Set) IndicatorVector(universe []string) []bool {
+	vec := make([]bool, len(universe))
+	for i, elt := range universe {
+		_, vec[i] = s[elt]
+	}
+	return vec
+}
+
+// HammingDistance returns the number of positions at which the indicator
+// vectors a and b differ.  If the vectors have different lengths, the missing
+// positions of the shorter one are treated as false.
+func HammingDistance(a, b []bool) int {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	n := 0
+	for i, v := range a {
+		if i < len(b) {
+			if v != b[i] {
+				n++
+			}
+		} else if v {
+			n++
+		}
+	}
+	return n
+}
+
+// Shard partitions s into n disjoint sets whose union is s, assigning each
+// element elt to the set at index hash(elt) % n.  If hash == nil, a 64-bit
+// FNV-1a hash with a mixing finalizer is used, so that the low-order bits of
+// the hash are well distributed.  Shards that receive no elements are nil.
+// If n ≤ 0 the result is nil.
+func (s Set) Shard(n int, hash func(string) uint64) []Set {
+	if n <= 0 {
+		return nil
+	} else if hash == nil {
+		hash = hash64
+	}
+	shards := make([]Set, n)
+	for k := range s {
+		shards[hash(k)%uint64(n)].Add(k)
+	}
+	return shards
+}
+
+// ShardSizes returns the number of elements of s assigned to each of n shards,
+// as by Shard, without constructing the shards.  If hash == nil, the same
+// default hash as Shard is used.  If n ≤ 0 the result is nil.
+func (s Set) ShardSizes(n int, hash func(string) uint64) []int {
+	if n <= 0 {
+		return nil
+	} else if hash == nil {
+		hash = hash64
+	}
+	sizes := make([]int, n)
+	for k := range s {
+		sizes[hash(k)%uint64(n)]++
+	}
+	return sizes
+}
+
+// MergeSortedKeys returns an ordered slice of the elements in the union of the
+// given sets, without duplicates.  It sorts the elements of each set
+// separately and merges the results, which avoids constructing the union as
+// an intermediate set.  The result is nil if all the sets are empty.
+func MergeSortedKeys(sets ...Set) []string {
+	var h mergeHeap
+	total := 0
+	for _, s := range sets {
+		if !s.Empty() {
+			h = append(h, s.Elements())
+			total += len(s)
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+	heap.Init(&h)
+
+	out := make([]string, 0, total)
+	for len(h) != 0 {
+		elt := h[0][0]
+		if n := len(out); n == 0 || out[n-1] != elt {
+			out = append(out, elt)
+		}
+		if h[0] = h[0][1:]; len(h[0]) == 0 {
+			heap.Pop(&h)
+		} else {
+			heap.Fix(&h, 0)
+		}
+	}
+	return out
+}
+
+// mergeHeap implements heap.Interface for a k-way merge of sorted slices,
+// ordered by the first element of each.  All the slices must be non-empty.
+type mergeHeap [][]string
+
+func (h mergeHeap) Len() int            { return len(h) }
+func (h mergeHeap) Less(i, j int) bool  { return h[i][0] < h[j][0] }
+func (h mergeHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *mergeHeap) Push(x interface{}) { *h = append(*h, x.([]string)) }
+func (h *mergeHeap) Pop() interface{} {
+	old := *h
+	last := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return last
+}
+
+// A Change records a difference in the membership of Key between two sets.
+type Change struct {
+	Key   string
+	Added bool // true if Key was added, false if it was removed
+}
+
+// Journal returns the changes needed to transform s into s2, in order by key.
+// Each element of s2 that is not in s is reported as added, and each element
+// of s that is not in s2 is reported as removed.  If s and s2 are equal, the
+// result is nil.
+func (s Set) Journal(s2 Set) []Change {
+	var out []Change
+	a, b := s.Elements(), s2.Elements()
+	for len(a) != 0 && len(b) != 0 {
+		switch {
+		case a[0] < b[0]:
+			out = append(out, Change{Key: a[0]})
+			a = a[1:]
+		case b[0] < a[0]:
+			out = append(out, Change{Key: b[0], Added: true})
+			b = b[1:]
+		default:
+			a, b = a[1:], b[1:]
+		}
+	}
+	for _, k := range a {
+		out = append(out, Change{Key: k})
+	}
+	for _, k := range b {
+		out = append(out, Change{Key: k, Added: true})
+	}
+	return out
+}
+
+// TopK returns up to k elements of s having the highest scores, in descending
+// order of score.  Elements with equal scores are ordered lexicographically.
+// Elements whose score is NaN rank below all others.  If k ≤ 0 or s is
+// empty, the result is nil.
+func (s Set) TopK(k int, score func(string) float64) []string {
+	if k <= 0 || s.Empty() {
+		return nil
+	} else if k > len(s) {
+		k = len(s)
+	}
+
+	// Maintain a heap of the best k elements seen so far, with the worst of
+	// them at the root so it can be replaced by a better candidate.
+	h := &scoreHeap{elts: make([]scored, 0, k)}
+	for elt := range s {
+		c := scored{elt, score(elt)}
+		if len(h.elts) < k {
+			heap.Push(h, c)
+		} else if h.better(c, h.elts[0]) {
+			h.elts[0] = c
+			heap.Fix(h, 0)
+		}
+	}
+	out := make([]string, len(h.elts))
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = heap.Pop(h).(scored).elt
+	}
+	return out
+}
+
+type scored struct {
+	elt   string
+	score float64
+}
+
+// scoreHeap implements heap.Interface for TopK, with the worst element at the
+// root.
+type scoreHeap struct{ elts []scored }
+
+// better reports whether a ranks ahead of b.
+func (h *scoreHeap) better(a, b scored) bool {
+	if an, bn := math.IsNaN(a.score), math.IsNaN(b.score); an != bn {
+		return bn
+	} else if !an && a.score != b.score {
+		return a.score > b.score
+	}
+	return a.elt < b.elt
+}
+
+func (h *scoreHeap) Len() int           { return len(h.elts) }
+func (h *scoreHeap) Less(i, j int) bool { return h.better(h.elts[j], h.elts[i]) }
+func (h *scoreHeap) Swap(i, j int)      { h.elts[i], h.elts[j] = h.elts[j], h.elts[i] }
+func (h *scoreHeap) Push(x interface{}) { h.elts = append(h.elts, x.(scored)) }
+func (h *scoreHeap) Pop() interface{} {
+	last := h.elts[len(h.elts)-1]
+	h.elts = h.elts[:len(h.elts)-1]
+	return last
+}
+
+// OverlapsAtLeast reports whether the overlap coefficient of s and s2,
+// |s ∩ s2| / min(|s|, |s2|), is at least frac.  It stops counting the
+// intersection as soon as the threshold is reached.
+//
+// If either set is empty, the coefficient is taken to be 1 if both are empty
+// and 0 otherwise.
+func (s Set) OverlapsAtLeast(s2 Set, frac float64) bool {
+	a, b := s, s2
+	if len(b) < len(a) {
+		a, b = b, a // Iterate over the smaller set
+	}
+	if len(a) == 0 {
+		if len(b) == 0 {
+			return frac <= 1
+		}
+		return frac <= 0
+	}
+	small := float64(len(a))
+	if frac <= 0 {
+		return true
+	}
+	n := 0
+	for k := range a {
+		if _, ok := b[k]; ok {
+			n++
+			if float64(n)/small >= frac {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FromEnv returns a Set constructed from the variables in the environment of
+// the current process whose names match re.  If values is false, the set
+// contains the names of the matching variables; otherwise it contains their
+// values.  If re == nil, every variable matches.
+func FromEnv(re *regexp.Regexp, values bool) Set {
+	var set Set
+	for _, kv := range os.Environ() {
+		name, value := kv, ""
+		if i := strings.Index(kv, "="); i >= 0 {
+			name, value = kv[:i], kv[i+1:]
+		}
+		if re != nil && !re.MatchString(name) {
+			continue
+		} else if values {
+			set.Add(value)
+		} else {
+			set.Add(name)
+		}
+	}
+	return set
+}
+
+// CountWhere returns the number of elements of s for which f returns true.
+// It is equivalent to s.Count(f).
+func (s Set) CountWhere(f func(string) bool) int { return s.Count(f) }
+
+// Histogram partitions the elements of s into buckets by the value of key,
+// and returns a map from each key value to the number of elements having that
+// key.  The result is nil if s is empty.
+func (s Set) Histogram(key func(string) int) map[int]int {
+	if s.Empty() {
+		return nil
+	}
+	h := make(map[int]int)
+	for k := range s {
+		h[key(k)]++
+	}
+	return h
+}
+
+// MarshalGzip encodes the elements of s in sorted order as newline-terminated
+// lines, compressed with gzip at the default compression level.  It reports
+// an error if any element of s contains a newline.
+func (s Set) MarshalGzip() ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	for _, elt := range s.Elements() {
+		if strings.Contains(elt, "\n") {
+			return nil, fmt.Errorf("element %q contains a newline", elt)
+		}
+		io.WriteString(w, elt)
+		io.WriteString(w, "\n")
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalGzip decodes a Set from data in the format written by MarshalGzip.
+// The result is nil if the encoded set is empty.
+func UnmarshalGzip(data []byte) (Set, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(r)
+	var set Set
+	for {
+		line, err := br.ReadString('\n')
+		if err == io.EOF {
+			if line != "" {
+				return nil, fmt.Errorf("unterminated element %q", line)
+			}
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		set.Add(strings.TrimSuffix(line, "\n"))
+	}
+	if err := r.Close(); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// JaccardSimilarity returns the Jaccard index of s and s2,
+// |s ∩ s2| / |s ∪ s2|.  If both sets are empty, the result is 1.
+func (s Set) JaccardSimilarity(s2 Set) float64 {
+	if s.Empty() && s2.Empty() {
+		return 1
+	}
+	n := IntersectCount(s, s2)
+	return float64(n) / float64(len(s)+len(s2)-n)
+}
+
+// DiceSimilarity returns the Sørensen–Dice coefficient of s and s2,
+// 2|s ∩ s2| / (|s| + |s2|).  If both sets are empty, the result is 1.
+func (s Set) DiceSimilarity(s2 Set) float64 {
+	if s.Empty() && s2.Empty() {
+		return 1
+	}
+	return 2 * float64(IntersectCount(s, s2)) / float64(len(s)+len(s2))
+}
+
+// Closest returns the index of the candidate with the greatest Jaccard
+// similarity to s, together with that similarity.  If several candidates are
+// equally similar, the lowest index is returned.  If there are no candidates,
+// Closest returns -1, 0.
+func (s Set) Closest(candidates ...Set) (int, float64) {
+	best, bestSim := -1, 0.0
+	for i, c := range candidates {
+		if sim := s.JaccardSimilarity(c); best < 0 || sim > bestSim {
+			best, bestSim = i, sim
+		}
+	}
+	return best, bestSim
+}
+
+// Exclusives returns, for each of the given sets, the subset of its elements
+// that do not belong to any of the other sets.  The ith element of the result
+// corresponds to sets[i], and is nil if that set has no exclusive elements.
+func Exclusives(sets ...Set) []Set {
+	count := make(map[string]int)
+	for _, s := range sets {
+		for k := range s {
+			count[k]++
+		}
+	}
+	out := make([]Set, len(sets))
+	for i, s := range sets {
+		for k := range s {
+			if count[k] == 1 {
+				out[i].Add(k)
+			}
+		}
+	}
+	return out
+}
+
+// CoreShell returns the core of the given sets, the elements common to all of
+// them, and the shell, the elements that belong to some but not all of them.
+// The core and shell are disjoint, and their union is the union of the sets.
+func CoreShell(sets ...Set) (core, shell Set) {
+	count := make(map[string]int)
+	for _, s := range sets {
+		for k := range s {
+			count[k]++
+		}
+	}
+	for k, n := range count {
+		if n == len(sets) {
+			core.Add(k)
+		} else {
+			shell.Add(k)
+		}
+	}
+	return
+}
+
+// Rename returns a new set in which each element of s that is a key of m is
+// replaced by its corresponding value, and other elements are unchanged.
+// Elements that are renamed to the same value, or to the value of another
+// element, collapse into a single element of the result.  Keys of m that are
+// not in s are ignored.
+func (s Set) Rename(m map[string]string) Set {
+	return s.Map(func(elt string) string {
+		if v, ok := m[elt]; ok {
+			return v
+		}
+		return elt
+	})
+}
+
+// ContainsOr reports whether s contains x or, if s is empty, whether fallback
+// contains x.  This models a set with a default value used when it is unset.
+func (s Set) ContainsOr(x string, fallback Set) bool {
+	if s.Empty() {
+		return fallback.Has(x)
+	}
+	return s.Has(x)
+}
+
+// SplitStable partitions s into two disjoint sets whose union is s, assigning
+// each element elt to a if the low-order bit of hash(elt) is 0, and to b
+// otherwise.  If hash == nil, a 64-bit FNV-1a hash with a mixing finalizer is
+// used, so that the low-order bit depends on every bit of the input.  For a
+// given hash, the split is reproducible across runs.
+func (s Set) SplitStable(hash func(string) uint64) (a, b Set) {
+	if hash == nil {
+		hash = hash64
+	}
+	for k := range s {
+		if hash(k)&1 == 0 {
+			a.Add(k)
+		} else {
+			b.Add(k)
+		}
+	}
+	return
+}
+
+// SelectGlob returns the subset of s whose elements match the given shell
+// file name pattern, as defined by filepath.Match.  It reports an error
+// (filepath.ErrBadPattern) if the pattern is malformed, even if s is empty.
+func (s Set) SelectGlob(pattern string) (Set, error) {
+	return s.SelectGlobs(pattern)
+}
+
+// SelectGlobs returns the subset of s whose elements match any of the given
+// shell file name patterns, as defined by filepath.Match.  It reports an
+// error (filepath.ErrBadPattern) if any of the patterns is malformed.
+//
+// Since filepath.Match stops checking a pattern when a match fails, each
+// separator-delimited segment of each pattern is checked before matching, so
+// that a malformed pattern is reported even if it matches nothing.
+func (s Set) SelectGlobs(patterns ...string) (Set, error) {
+	for _, p := range patterns {
+		for _, seg := range globSegments(p) {
+			if _, err := filepath.Match(seg, ""); err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", p, err)
+			}
+		}
+	}
+	var out Set
+	for elt := range s {
+		for _, p := range patterns {
+			ok, err := filepath.Match(p, elt)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", p, err)
+			} else if ok {
+				out.Add(elt)
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+// globSegments splits the shell file name pattern p at each separator that is
+// not escaped or inside a character class.
+func globSegments(p string) []string {
+	var segs []string
+	inClass := false
+	start := 0
+	for i := 0; i < len(p); i++ {
+		switch c := p[i]; {
+		case c == '\\' && filepath.Separator != '\\':
+			i++ // skip the escaped character
+		case c == '[':
+			inClass = true
+		case c == ']':
+			inClass = false
+		case c == filepath.Separator && !inClass:
+			segs = append(segs, p[start:i])
+			start = i + 1
+		}
+	}
+	return append(segs, p[start:])
+}
+
+// DiffMasked constructs the set difference (s \ s2) \ mask in a single pass,
+// without constructing s \ s2 as an intermediate set.
+func (s Set) DiffMasked(s2, mask Set) Set {
+	var out Set
+	for k := range s {
+		if _, ok := s2[k]; ok {
+			continue
+		} else if _, ok := mask[k]; ok {
+			continue
+		}
+		out.Add(k)
+	}
+	return out
+}
+
+// SelectRegexp returns the subset of s whose elements match re.
+func (s Set) SelectRegexp(re *regexp.Regexp) Set { return s.Select(re.MatchString) }
+
+// DiscardRegexp removes the elements of s that match re in-place, and returns
+// the number of elements removed.
+func (s Set) DiscardRegexp(re *regexp.Regexp) int {
+	in := len(s)
+	for k := range s {
+		if re.MatchString(k) {
+			delete(s, k)
+		}
+	}
+	return in - len(s)
+}
+
+// UnionValues constructs the union of the sets that are values of m.  The
+// result is nil if all the values are empty.
+func UnionValues(m map[string]Set) Set {
+	n := 0
+	for _, s := range m {
+		n += len(s)
+	}
+	if n == 0 {
+		return nil
+	}
+	set := make(Set, n)
+	for _, s := range m {
+		for k := range s {
+			set[k] = struct{}{}
+		}
+	}
+	return set
+}
+
+// IntersectValues constructs the intersection of the sets that are values of
+// m.  The result is nil if m is empty, or if any of its values is empty.
+func IntersectValues(m map[string]Set) Set {
+	var pivot Set
+	first := true
+	for _, s := range m {
+		if s.Empty() {
+			return nil
+		} else if first || len(s) < len(pivot) {
+			pivot, first = s, false
+		}
+	}
+	var out Set
+	for k := range pivot {
+		if containedInAll(k, m) {
+			out.Add(k)
+		}
+	}
+	return out
+}
+
+// containedInAll reports whether elt belongs to every value of m.
+func containedInAll(elt string, m map[string]Set) bool {
+	for _, s := range m {
+		if _, ok := s[elt]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// EqualsMap reports whether s has exactly the same elements as the keys of m.
+func (s Set) EqualsMap(m map[string]struct{}) bool { return s.Equals(Set(m)) }
+
+// EqualsBoolMap reports whether s has exactly the same elements as the keys
+// of m whose values are true.  Keys mapped to false are treated as absent, so
+// len(m) may exceed len(s) even when the two are equal; only len(m) < len(s)
+// rules out equality without examining the entries of m.
+func (s Set) EqualsBoolMap(m map[string]bool) bool {
+	if len(m) < len(s) {
+		return false
+	}
+	n := 0
+	for k, ok := range m {
+		if !ok {
+			continue
+		} else if _, in := s[k]; !in {
+			return false
+		}
+		n++
+	}
+	return n == len(s)
+}
+
+// MapErr returns the Set that results from applying f to each element of s
+// for which f succeeds, along with the errors reported by f for the others.
+// Each error is wrapped to identify the element that caused it, and the
+// errors are ordered by element.  If f succeeds for every element, the second
+// result is nil.
+func (s Set) MapErr(f func(string) (string, error)) (Set, []error) {
+	var out Set
+	var errs []error
+	for _, elt := range s.Elements() {
+		v, err := f(elt)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("element %q: %w", elt, err))
+			continue
+		}
+		out.Add(v)
+	}
+	return out, errs
+}
+
+// CommonPrefix returns the longest string that is a prefix of every element
+// of s.  It returns "" if s is empty or its elements share no prefix.  The
+// prefix never ends partway through a UTF-8 encoded rune.
+func (s Set) CommonPrefix() string {
+	var prefix string
+	first := true
+	for k := range s {
+		if first {
+			prefix, first = k, false
+			continue
+		}
+		n := 0
+		for n < len(prefix) && n < len(k) && prefix[n] == k[n] {
+			n++
+		}
+		for n > 0 && n < len(prefix) && !utf8.RuneStart(prefix[n]) {
+			n--
+		}
+		if prefix = prefix[:n]; prefix == "" {
+			break
+		}
+	}
+	return prefix
+}
+
+// ContainsPrefixOf reports whether some element of s is a prefix of p, and if
+// so returns the longest such element.  Note that the empty string, if it is
+// an element of s, is a prefix of every string.
+//
+// The current implementation is a linear scan of s.
+func (s Set) ContainsPrefixOf(p string) (string, bool) {
+	var best string
+	found := false
+	for k := range s {
+		if strings.HasPrefix(p, k) && (!found || len(k) > len(best)) {
+			best, found = k, true
+		}
+	}
+	return best, found
+}
+
+// HasElementWithPrefix reports whether some element of s begins with prefix.
+// Every element begins with the empty prefix, so HasElementWithPrefix("") is
+// true for any non-empty s.
+//
+// The current implementation is a linear scan of s.
+func (s Set) HasElementWithPrefix(prefix string) bool {
+	for k := range s {
+		if strings.HasPrefix(k, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsClosedUnder reports whether s is closed under f, that is, whether f(x) is
+// an element of s for every element x of s.  The empty set is closed under
+// every function.
+func (s Set) IsClosedUnder(f func(string) string) bool {
+	for k := range s {
+		if _, ok := s[f(k)]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// compactRatio is the factor by which a set must have shrunk below its peak
+// size before Compact reallocates it.
+const compactRatio = 4
+
+// Compact reallocates the storage of *s to fit its current contents, if *s
+// has shrunk well below peak, and reports whether a reallocation occurred.
+// Go maps do not release storage as elements are removed, so a set that once
+// held many more elements than it now does may retain much more memory than
+// it needs.
+//
+// A Set does not record its peak size, and Go does not expose the capacity of
+// a map, so the caller must supply peak, the largest number of elements *s is
+// known to have held.  Compact reallocates only when len(*s) is at most
+// peak/4, at a cost proportional to len(*s); otherwise it does nothing and
+// returns false.  If *s is empty but not nil, it is set to nil, which releases
+// its storage entirely, regardless of peak.
+func (s *Set) Compact(peak int) bool {
+	if *s == nil {
+		return false
+	} else if len(*s) == 0 {
+		*s = nil
+		return true
+	} else if len(*s)*compactRatio > peak {
+		return false // not shrunk enough to be worthwhile
+	}
+	c := make(Set, len(*s))
+	for k := range *s {
+		c[k] = struct{}{}
+	}
+	*s = c
+	return true
+}
+
+// Quotient partitions s into equivalence classes, and returns a map from the
+// representative of each class to the set of elements in that class.  The
+// representative of an element x is rep(x); two elements are equivalent if
+// they have the same representative.  The result is nil if s is empty.
+//
+// The function rep should be idempotent, so that rep(rep(x)) == rep(x), but
+// the representative of a class need not itself be an element of s.
+func (s Set) Quotient(rep func(string) string) map[string]Set {
+	if s.Empty() {
+		return nil
+	}
+	m := make(map[string]Set)
+	for k := range s {
+		r := rep(k)
+		c := m[r]
+		c.Add(k)
+		m[r] = c
+	}
+	return m
+}
+
+// FromTokens returns a Set of the tokens read from r, as delimited by split.
+// If split == nil, bufio.ScanWords is used.  Tokens may be up to maxTokenSize
+// bytes long, and longer tokens cause FromTokens to report bufio.ErrTooLong.
+// If maxTokenSize ≤ 0, bufio.MaxScanTokenSize is used.  If reading fails,
+// FromTokens returns the tokens read before the failure along with the error.
+func FromTokens(r io.Reader, split bufio.SplitFunc, maxTokenSize int) (Set, error) {
+	if split == nil {
+		split = bufio.ScanWords
+	}
+	if maxTokenSize <= 0 {
+		maxTokenSize = bufio.MaxScanTokenSize
+	}
+	sc := bufio.NewScanner(r)
+	sc.Split(split)
+	sc.Buffer(nil, maxTokenSize)
+	var set Set
+	for sc.Scan() {
+		set.Add(sc.Text())
+	}
+	return set, sc.Err()
+}
+
+// FormatDiff returns a human-readable description of the changes from before
+// to after, as a sequence of lines in order by element.  Each element removed
+// is listed on a line prefixed with "-", and each element added on a line
+// prefixed with "+".  Unchanged elements are omitted, so the result is ""
+// when before and after are equal.
+func FormatDiff(before, after Set) string {
+	var sb strings.Builder
+	WriteDiff(&sb, before, after)
+	return sb.String()
+}
+
+// WriteDiff writes the changes from before to after to w, in the format
+// produced by FormatDiff.
+func WriteDiff(w io.Writer, before, after Set) error {
+	bw := bufio.NewWriter(w)
+	for _, c := range before.Journal(after) {
+		if c.Added {
+			bw.WriteByte('+')
+		} else {
+			bw.WriteByte('-')
+		}
+		bw.WriteString(c.Key)
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// Range returns the subset of s whose elements x satisfy lo ≤ x ≤ hi in
+// lexicographic order.  The result is nil if hi < lo.
+//
+// The current implementation is a linear scan of s.
+func (s Set) Range(lo, hi string) Set {
+	if hi < lo {
+		return nil
+	}
+	return s.Select(func(elt string) bool { return lo <= elt && elt <= hi })
+}
+
+// CanonicalKey returns a string encoding of s that is suitable for use as a
+// map key: two sets have the same CanonicalKey if and only if they are equal.
+// The encoding consists of the sorted elements of s, each preceded by its
+// length in decimal and a colon, e.g., "1:a3:b,c" for {"a", "b,c"}.
+func (s Set) CanonicalKey() string {
+	elts := s.Elements()
+	n := 0
+	for _, elt := range elts {
+		n += len(elt) + 4
+	}
+	buf := make([]byte, 0, n)
+	for _, elt := range elts {
+		buf = strconv.AppendInt(buf, int64(len(elt)), 10)
+		buf = append(buf, ':')
+		buf = append(buf, elt...)
+	}
+	return string(buf)
+}
+
+// Since returns the elements of s that are not in baseline, that is, the
+// elements added since baseline was taken.  It is equivalent to
+// s.Diff(baseline).
+func (s Set) Since(baseline Set) Set { return s.Diff(baseline) }
+
+// Gone returns the elements of baseline that are not in s, that is, the
+// elements removed since baseline was taken.  It is equivalent to
+// baseline.Diff(s).
+func (s Set) Gone(baseline Set) Set { return baseline.Diff(s) }
+
+// ParallelUnion constructs the set of all the strings in inputs, dividing the
+// inputs among n concurrent goroutines that each build a partial set, and then
+// merging the partial sets.  If n < 1, a single goroutine is used.  The result
+// is nil if inputs contains no strings.
+func ParallelUnion(n int, inputs [][]string) Set {
+	if n < 1 {
+		n = 1
+	}
+	if n > len(inputs) {
+		n = len(inputs)
+	}
+	parts := make([]Set, n)
+	var wg sync.WaitGroup
+	for i := range parts {
+		lo, hi := i*len(inputs)/n, (i+1)*len(inputs)/n
+		wg.Add(1)
+		go func(i int, chunk [][]string) {
+			defer wg.Done()
+			var s Set
+			for _, in := range chunk {
+				s.Add(in...)
+			}
+			parts[i] = s
+		}(i, inputs[lo:hi])
+	}
+	wg.Wait()
+
+	// Merge into the largest partial set to minimize copying.
+	var out Set
+	for _, p := range parts {
+		if len(p) > len(out) {
+			out = p
+		}
+	}
+	for _, p := range parts {
+		out.Update(p)
+	}
+	if out.Empty() {
+		return nil
+	}
+	return out
+}
+
+// Normalize returns the Set that results from applying f to each element of
+// s, as Map does, and also reports which elements were merged: collisions
+// maps each element of the result that was produced from more than one
+// element of s to those elements, in sorted order.  If no elements were
+// merged, collisions is nil.
+func (s Set) Normalize(f func(string) string) (out Set, collisions map[string][]string) {
+	from := make(map[string][]string, len(s))
+	for _, elt := range s.Elements() {
+		v := f(elt)
+		from[v] = append(from[v], elt)
+	}
+	for v, srcs := range from {
+		out.Add(v)
+		if len(srcs) > 1 {
+			if collisions == nil {
+				collisions = make(map[string][]string)
+			}
+			collisions[v] = srcs
+		}
+	}
+	return
+}
+
+// Fold returns the Set that results from case-folding each element of s, and
+// reports which elements were merged, as described by Normalize.  Elements are
+// folded by converting them to upper case and then to lower case, so that,
+// for example, "Σ", "σ", and "ς" all fold to "σ".  Only single-rune case
+// mappings are applied, so "ß" and "SS" are not merged.
+func (s Set) Fold() (Set, map[string][]string) { return s.Normalize(foldCase) }
+
+func foldCase(s string) string { return strings.ToLower(strings.ToUpper(s)) }
+
+// WouldAdd reports whether s.Add(x) would change s, that is, whether x is not
+// already an element of s.  It does not modify s.
+func (s Set) WouldAdd(x string) bool { return !s.Has(x) }
+
+// WouldRemove reports whether s.Discard(x) would change s, that is, whether x
+// is an element of s.  It does not modify s.
+func (s Set) WouldRemove(x string) bool { return s.Has(x) }
+
+// MaxN returns the n greatest elements of s under the ordering defined by
+// less, in order from greatest to least.  If less == nil, elements are
+// ordered lexicographically.  If n ≥ len(s), all the elements of s are
+// returned; if n ≤ 0, the result is nil.
+//
+// MaxN uses a heap of size n, so its cost is O(len(s) log n).
+func (s Set) MaxN(n int, less func(a, b string) bool) []string {
+	if less == nil {
+		less = func(a, b string) bool { return a < b }
+	}
+	return s.bestN(n, func(a, b string) bool { return less(b, a) })
+}
+
+// MinN returns the n least elements of s under the ordering defined by less,
+// in order from least to greatest.  If less == nil, elements are ordered
+// lexicographically.  If n ≥ len(s), all the elements of s are returned; if
+// n ≤ 0, the result is nil.
+//
+// MinN uses a heap of size n, so its cost is O(len(s) log n).
+func (s Set) MinN(n int, less func(a, b string) bool) []string {
+	if less == nil {
+		less = func(a, b string) bool { return a < b }
+	}
+	return s.bestN(n, less)
+}
+
+// bestN returns the n best elements of s, best first, where better(a, b)
+// reports whether a ranks ahead of b.
+func (s Set) bestN(n int, better func(a, b string) bool) []string {
+	if n <= 0 || s.Empty() {
+		return nil
+	} else if n > len(s) {
+		n = len(s)
+	}
+	h := &bestHeap{elts: make([]string, 0, n), better: better}
+	for elt := range s {
+		if len(h.elts) < n {
+			heap.Push(h, elt)
+		} else if better(elt, h.elts[0]) {
+			h.elts[0] = elt
+			heap.Fix(h, 0)
+		}
+	}
+	out := make([]string, len(h.elts))
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = heap.Pop(h).(string)
+	}
+	return out
+}
+
+// bestHeap implements heap.Interface for bestN, with the worst element at
+// the root.
+type bestHeap struct {
+	elts   []string
+	better func(a, b string) bool
+}
+
+func (h *bestHeap) Len() int           { return len(h.elts) }
+func (h *bestHeap) Less(i, j int) bool { return h.better(h.elts[j], h.elts[i]) }
+func (h *bestHeap) Swap(i, j int)      { h.elts[i], h.elts[j] = h.elts[j], h.elts[i] }
+func (h *bestHeap) Push(x interface{}) { h.elts = append(h.elts, x.(string)) }
+func (h *bestHeap) Pop() interface{} {
+	last := h.elts[len(h.elts)-1]
+	h.elts = h.elts[:len(h.elts)-1]
+	return last
+}
+
+// Scope returns a new set containing, for each element of s that begins with
+// prefix, the remainder of that element after the prefix.  Elements that do
+// not begin with prefix are excluded.  An element equal to prefix contributes
+// the empty string.  For example, if s = {"db.host", "db.port", "log.level"}:
+//
+//	s.Scope("db.") == {"host", "port"}
+//
+// Since distinct elements sharing a prefix have distinct remainders, no two
+// elements collapse together, and the result has exactly as many elements as
+// s has elements with the given prefix.
+func (s Set) Scope(prefix string) Set {
+	var out Set
+	for elt := range s {
+		if strings.HasPrefix(elt, prefix) {
+			out.Add(elt[len(prefix):])
+		}
+	}
+	return out
+}
+
+// IntersectEach calls f for each element of the intersection s ∩ s2, without
+// constructing the intersection.  The order in which elements are visited is
+// unspecified.
+func (s Set) IntersectEach(s2 Set, f func(string)) {
+	s.IntersectEachWhile(s2, func(elt string) bool { f(elt); return true })
+}
+
+// IntersectEachWhile calls f for each element of the intersection s ∩ s2,
+// without constructing the intersection, until f returns false.  It reports
+// whether every element was visited, that is, false if f stopped the
+// iteration early.  The order in which elements are visited is unspecified.
+func (s Set) IntersectEachWhile(s2 Set, f func(string) bool) bool {
+	a, b := s, s2
+	if len(b) < len(a) {
+		a, b = b, a // Iterate over the smaller set
+	}
+	for k := range a {
+		if _, ok := b[k]; ok && !f(k) {
+			return false
+		}
+	}
+	return true
+}
+
+// DiffEach calls f for each element of the set difference s \ s2, without
+// constructing the difference.  The order in which elements are visited is
+// unspecified.
+func (s Set) DiffEach(s2 Set, f func(string)) {
+	s.DiffEachWhile(s2, func(elt string) bool { f(elt); return true })
+}
+
+// DiffEachWhile calls f for each element of the set difference s \ s2,
+// without constructing the difference, until f returns false.  It reports
+// whether every element was visited.  The order in which elements are visited
+// is unspecified.
+func (s Set) DiffEachWhile(s2 Set, f func(string) bool) bool {
+	for k := range s {
+		if _, ok := s2[k]; !ok && !f(k) {
+			return false
+		}
+	}
+	return true
+}
+
+// UnionEach calls f exactly once for each element of the union s ∪ s2,
+// without constructing the union.  The order in which elements are visited is
+// unspecified.
+func (s Set) UnionEach(s2 Set, f func(string)) {
+	s.UnionEachWhile(s2, func(elt string) bool { f(elt); return true })
+}
+
+// UnionEachWhile calls f exactly once for each element of the union s ∪ s2,
+// without constructing the union, until f returns false.  It reports whether
+// every element was visited.  The order in which elements are visited is
+// unspecified.
+func (s Set) UnionEachWhile(s2 Set, f func(string) bool) bool {
+	for k := range s {
+		if !f(k) {
+			return false
+		}
+	}
+	return s2.DiffEachWhile(s, f)
+}
+
+// CheckSchema reports whether s is a valid set of keys for a schema with the
+// given required and optional keys.  It returns nil if s contains every key
+// in required and no keys outside required ∪ optional.  Otherwise, it returns
+// an error listing, in sorted order, the missing required keys and the
+// unexpected keys.  A key that is both required and optional is treated as
+// required.
+func (s Set) CheckSchema(required, optional Set) error {
+	missing := required.Diff(s)
+	var extra Set
+	for key := range s {
+		if !required.Has(key) && !optional.Has(key) {
+			extra.Add(key)
+		}
+	}
+	var msgs []string
+	if !missing.Empty() {
+		msgs = append(msgs, "missing required keys "+missing.JoinQuoted(", "))
+	}
+	if !extra.Empty() {
+		msgs = append(msgs, "unexpected keys "+extra.JoinQuoted(", "))
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(msgs, "; "))
+}
+
+// MinimalPrefixes returns the subset of s consisting of those elements that
+// do not have another element of s as a prefix.  For example, if
+//
+//	s = {"a/", "a/b/", "a/c", "b/x", "b/y"}
+//
+// then s.MinimalPrefixes() == {"a/", "b/x", "b/y"}.  Distinct elements of
+// equal length cannot be prefixes of one another, so both are retained.  If s
+// contains "", the result is {""}.
+func (s Set) MinimalPrefixes() Set {
+	var out Set
+	var last string
+	for i, elt := range s.Elements() {
+		if i > 0 && strings.HasPrefix(elt, last) {
+			continue // covered by a previously-kept element
+		}
+		out.Add(elt)
+		last = elt
+	}
+	return out
+}
+
+// AddTracked adds x to *s in-place, and reports whether x became the new
+// least element (newMin) or greatest element (newMax) of *s.  When x is the
+// first element added to an empty set, both results are true.  If x was
+// already present, nothing changes and both results are false.  If *s == nil,
+// a new set is allocated.
+//
+// A Set does not record its extremes, so AddTracked compares x against the
+// other elements, stopping as soon as it finds elements on both sides of x.
+// In the worst case this costs O(len(*s)).
+func (s *Set) AddTracked(x string) (newMin, newMax bool) {
+	if s.Has(x) {
+		return false, false
+	}
+	newMin, newMax = true, true
+	for elt := range *s {
+		if elt < x {
+			newMin = false
+		} else {
+			newMax = false
+		}
+		if !newMin && !newMax {
+			break
+		}
+	}
+	s.Add(x)
+	return
+}
+
+// PrefixCount splits each element of s into segments separated by sep, and
+// returns a map from each distinct prefix of depth segments to the number of
+// elements having that prefix.  Elements with depth or fewer segments are
+// counted under their full value.  For example, if
+//
+//	s = {"db.host", "db.pool.min", "db.pool.max", "log"}
+//
+// then s.PrefixCount(".", 1) == {"db": 3, "log": 1}.  If s is empty or
+// depth ≤ 0, the result is nil.
+func (s Set) PrefixCount(sep string, depth int) map[string]int {
+	if s.Empty() || depth <= 0 {
+		return nil
+	}
+	count := make(map[string]int)
+	for elt := range s {
+		parts := strings.SplitN(elt, sep, depth+1)
+		if len(parts) > depth {
+			count[strings.Join(parts[:depth], sep)]++
+		} else {
+			count[elt]++
+		}
+	}
+	return count
+}
+
+// UnionSlice constructs the union s ∪ New(elts...), without constructing a set
+// from elts.  Duplicates in elts are ignored.
+func (s Set) UnionSlice(elts []string) Set {
+	if len(elts) == 0 {
+		return s
+	}
+	set := make(Set, len(s)+len(elts))
+	for k := range s {
+		set[k] = struct{}{}
+	}
+	for _, elt := range elts {
+		set[elt] = struct{}{}
+	}
+	return set
+}
+
+// IntersectSlice constructs the intersection s ∩ New(elts...), without
+// constructing a set from elts.  Duplicates in elts are ignored.  It checks
+// each element of elts against s, so the cost is O(len(elts)) regardless of
+// the size of s.
+func (s Set) IntersectSlice(elts []string) Set {
+	if s.Empty() {
+		return nil
+	}
+	var out Set
+	for _, elt := range elts {
+		if _, ok := s[elt]; ok {
+			out.Add(elt)
+		}
+	}
+	return out
+}
+
+// DiffSlice constructs the set difference s \ New(elts...), without
+// constructing a set from elts.  Duplicates in elts are ignored.  It copies s
+// and deletes the elements of elts from the copy, at a cost of
+// O(len(s) + len(elts)).
+func (s Set) DiffSlice(elts []string) Set {
+	if s.Empty() || len(elts) == 0 {
+		return s
+	}
+	out := make(Set, len(s))
+	for k := range s {
+		out[k] = struct{}{}
+	}
+	for _, elt := range elts {
+		delete(out, elt)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// NGrams returns the set of all substrings of n runes that occur in any
+// element of s.  Elements with fewer than n runes contribute nothing.  Since
+// substrings are counted in runes rather than bytes, a multibyte character is
+// never split.  If n ≤ 0, the result is nil.
+func (s Set) NGrams(n int) Set {
+	if n <= 0 {
+		return nil
+	}
+	var out Set
+	var starts []int // byte offsets of each rune in the current element
+	for elt := range s {
+		starts = starts[:0]
+		for i := range elt {
+			starts = append(starts, i)
+		}
+		starts = append(starts, len(elt))
+		for i := 0; i+n < len(starts); i++ {
+			out.Add(elt[starts[i]:starts[i+n]])
+		}
+	}
+	return out
+}
@@ -1,7 +1,20 @@
 package stringset_test
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"path/filepath"
 	"reflect"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 
 	"bitbucket.org/creachadair/stringset"
@@ -118,6 +131,14 @@ func TestMembership(t *testing.T) {
 	if got, ok := stringset.New().Choose(nil); ok {
 		t.Errorf(`Choose(nil): got %v, want ""`, got)
 	}
+	if got, ok := s.Choose(nil); !ok {
+		t.Error("Choose(nil) on non-empty: missing element")
+	} else if !s.Contains(got) {
+		t.Errorf("Choose(nil) on non-empty: got %v, not in %v", got, s)
+	}
+	if got, ok := testSet(3).Choose(nil); !ok || got != testValues[3] {
+		t.Errorf("Choose(nil) on singleton: got %v, %v; want %v, true", got, ok, testValues[3])
+	}
 
 	// Test mutating selection.
 	if got, ok := s.Pop(func(s string) bool {
@@ -141,6 +162,22 @@ func TestMembership(t *testing.T) {
 	}
 }
 
+func TestHas(t *testing.T) {
+	s := testSet(0, 2, 4, 6, 8)
+	for i, v := range testValues {
+		if got, want := s.Has(v), i%2 == 0; got != want {
+			t.Errorf("%v.Has(%v): got %v, want %v", s, v, got, want)
+		}
+		if got, want := s.Has(v), s.Contains(v); got != want {
+			t.Errorf("%v.Has(%v): got %v, but Contains reports %v", s, v, got, want)
+		}
+	}
+	var empty stringset.Set
+	if empty.Has("") {
+		t.Error(`nil.Has(""): got true, want false`)
+	}
+}
+
 func TestContainsAny(t *testing.T) {
 	set := stringset.New(testValues[2:]...)
 	tests := []struct {
@@ -576,6 +613,15 @@ func (k keyer) Keys() []string {
 
 type uniq int
 
+// otherSet has the same underlying type as stringset.Set, but is distinct.
+type otherSet map[string]struct{}
+
+// otherMap is a named map type with string keys.
+type otherMap map[string]uniq
+
+// otherString is a named type whose underlying type is string.
+type otherString string
+
 func TestFromValues(t *testing.T) {
 	tests := []struct {
 		input interface{}
@@ -595,6 +641,15 @@ func TestFromValues(t *testing.T) {
 			t.Errorf("MapValues %v: got %v, want %v", test.input, got, want)
 		}
 	}
+
+	// Inputs that do not have the form map[T]string yield nil, not an empty set.
+	for _, input := range []interface{}{
+		nil, 3.5, "foo", testKeys(0, 1), map[string]int{"x": 1}, map[int]uniq{1: 2},
+	} {
+		if got := stringset.FromValues(input); got != nil {
+			t.Errorf("FromValues(%v): got %#v, want nil", input, got)
+		}
+	}
 }
 
 func TestFromKeys(t *testing.T) {
@@ -613,12 +668,19 @@ func TestFromKeys(t *testing.T) {
 		{keyer(testValues[:3]), testSet(0, 1, 2)},
 		{testSet(4, 7, 8), testSet(4, 7, 8)},
 		{map[string]struct{}{testValues[2]: {}, testValues[7]: {}}, testSet(2, 7)},
+		{otherSet(nil), nil},
+		{otherSet{testValues[3]: {}, testValues[5]: {}}, testSet(3, 5)},
+		{otherMap{testValues[1]: 1, testValues[6]: 2}, testSet(1, 6)},
+		{map[otherString]struct{}{"x": {}}, nil}, // key type is not string
 	}
 	for _, test := range tests {
 		got := stringset.FromKeys(test.input)
 		if !got.Equals(test.want) {
 			t.Errorf("FromKeys %v: got %v, want %v", test.input, got, test.want)
 		}
+		if test.want == nil && got != nil {
+			t.Errorf("FromKeys %v: got %#v, want nil", test.input, got)
+		}
 	}
 }
 
@@ -654,12 +716,37 @@ func TestContainsFunc(t *testing.T) {
 		{keyer{testValues[0]}, testValues[9], false},
 		{keyer(testKeys(0, 6, 9)), testValues[9], true},
 		{keyer(testKeys(0, 6, 7)), testValues[9], false},
+
+		{otherSet(nil), testValues[1], false},
+		{otherSet{testValues[1]: {}}, testValues[1], true},
+		{otherSet{testValues[2]: {}}, testValues[1], false},
+		{otherMap{testValues[8]: 0}, testValues[8], true},
+		{otherMap{testValues[8]: 0}, testValues[7], false},
+		{map[otherString]struct{}{otherString(testValues[0]): {}}, testValues[0], false},
+
+		{map[string]bool(nil), testValues[4], false},
+		{map[string]bool{testValues[4]: true}, testValues[4], true},
+		{map[string]bool{testValues[4]: false}, testValues[4], true},
+		{map[string]bool{testValues[5]: true}, testValues[4], false},
+		{map[string]string(nil), testValues[4], false},
+		{map[string]string{testValues[4]: ""}, testValues[4], true},
+		{map[string]string{testValues[5]: testValues[4]}, testValues[4], false},
+		{map[string]interface{}(nil), testValues[4], false},
+		{map[string]interface{}{testValues[4]: nil}, testValues[4], true},
+		{map[string]interface{}{testValues[5]: 1}, testValues[4], false},
 	}
 	for _, test := range tests {
 		got := stringset.Contains(test.input, test.needle)
 		if got != test.want {
 			t.Errorf("Contains(%+v, %v): got %v, want %v", test.input, test.needle, got, test.want)
 		}
+
+		// For maps, the reflection-based path must agree with the fast paths.
+		if reflect.ValueOf(test.input).Kind() == reflect.Map {
+			if got := stringset.ContainsReflect(test.input, test.needle); got != test.want {
+				t.Errorf("ContainsReflect(%+v, %v): got %v, want %v", test.input, test.needle, got, test.want)
+			}
+		}
 	}
 }
 
@@ -683,3 +770,2439 @@ func TestFromIndexed(t *testing.T) {
 		}
 	}
 }
+
+func TestDigest(t *testing.T) {
+	tests := []struct {
+		input stringset.Set
+		want  string
+	}{
+		{nil, "e3b0c44298fc1c14"},
+		{stringset.New(), "e3b0c44298fc1c14"},
+		{stringset.New("a", "b", "c"), "ac678da99e6e9ebf"},
+		{stringset.New("c", "b", "a", "b"), "ac678da99e6e9ebf"},
+		{stringset.New("ab", "c"), "c150b536a0d7450f"},
+	}
+	for _, test := range tests {
+		if got := test.input.Digest(); got != test.want {
+			t.Errorf("%v.Digest(): got %q, want %q", test.input, got, test.want)
+		}
+	}
+
+	// Any change in membership should change the digest.
+	s := stringset.New(testValues[:]...)
+	base := s.Digest()
+	for _, v := range testValues {
+		s.Discard(v)
+		if got := s.Digest(); got == base {
+			t.Errorf("Digest after discarding %q: got %q, want a different value", v, got)
+		}
+		s.Add(v)
+		if got := s.Digest(); got != base {
+			t.Errorf("Digest after restoring %q: got %q, want %q", v, got, base)
+		}
+	}
+}
+
+func TestPartitionByScore(t *testing.T) {
+	byLength := func(s string) float64 { return float64(len(s)) }
+	in := stringset.New("a", "bb", "ccc", "dd", "e", "ffff", "ggg")
+	tests := []struct {
+		buckets int
+		want    []stringset.Set
+	}{
+		{0, nil},
+		{-1, nil},
+		{1, []stringset.Set{in}},
+		{2, []stringset.Set{
+			stringset.New("a", "e", "bb", "dd"),
+			stringset.New("ccc", "ggg", "ffff"),
+		}},
+		{3, []stringset.Set{
+			stringset.New("a", "e", "bb"),
+			stringset.New("dd", "ccc"),
+			stringset.New("ggg", "ffff"),
+		}},
+		{7, []stringset.Set{
+			stringset.New("a"), stringset.New("e"), stringset.New("bb"),
+			stringset.New("dd"), stringset.New("ccc"), stringset.New("ggg"),
+			stringset.New("ffff"),
+		}},
+		{9, []stringset.Set{
+			stringset.New("a"), stringset.New("e"), stringset.New("bb"),
+			stringset.New("dd"), stringset.New("ccc"), stringset.New("ggg"),
+			stringset.New("ffff"), nil, nil,
+		}},
+	}
+	for _, test := range tests {
+		got := in.PartitionByScore(byLength, test.buckets)
+		if len(got) != len(test.want) {
+			t.Errorf("PartitionByScore(len, %d): got %d buckets, want %d", test.buckets, len(got), len(test.want))
+			continue
+		}
+		for i, b := range got {
+			if !b.Equals(test.want[i]) {
+				t.Errorf("PartitionByScore(len, %d) bucket %d: got %v, want %v", test.buckets, i, b, test.want[i])
+			}
+		}
+	}
+
+	if got := stringset.New().PartitionByScore(byLength, 3); len(got) != 3 {
+		t.Errorf("PartitionByScore on empty: got %d buckets, want 3", len(got))
+	}
+}
+
+func TestIntersectCount(t *testing.T) {
+	nat := stringset.New(testValues[:]...)
+	odd := testSet(1, 3, 5, 7, 9)
+	prime := testSet(2, 3, 5, 7)
+	tests := []struct {
+		sets []stringset.Set
+		want int
+	}{
+		{nil, 0},
+		{[]stringset.Set{nil}, 0},
+		{[]stringset.Set{nat}, 10},
+		{[]stringset.Set{nat, nat}, 10},
+		{[]stringset.Set{nat, odd}, 5},
+		{[]stringset.Set{nat, odd, prime}, 3},
+		{[]stringset.Set{prime, odd, nat}, 3},
+		{[]stringset.Set{odd, testSet(0, 2)}, 0},
+		{[]stringset.Set{nat, odd, stringset.New()}, 0},
+		{[]stringset.Set{nat, nil, prime}, 0},
+	}
+	for _, test := range tests {
+		if got := stringset.IntersectCount(test.sets...); got != test.want {
+			t.Errorf("IntersectCount(%v): got %d, want %d", test.sets, got, test.want)
+		}
+	}
+}
+
+func TestSymDiffAll(t *testing.T) {
+	a := testSet(0, 1, 2, 3, 4)
+	b := testSet(0, 4, 5, 6, 7)
+	c := testSet(3, 4, 8, 9)
+	d := testSet(0, 1, 8)
+
+	tests := []struct {
+		sets []stringset.Set
+		want []string
+	}{
+		{nil, nil},
+		{[]stringset.Set{nil, nil}, nil},
+		{[]stringset.Set{a}, a.Elements()},
+		{[]stringset.Set{a, a}, nil},
+		{[]stringset.Set{a, b}, a.SymDiff(b).Elements()},
+		{[]stringset.Set{c, a}, c.SymDiff(a).Elements()},
+		{[]stringset.Set{a, b, c}, testKeys(1, 2, 4, 5, 6, 7, 8, 9)},
+		{[]stringset.Set{a, a, a}, a.Elements()},
+		{[]stringset.Set{a, b, c, d}, testKeys(0, 2, 4, 5, 6, 7, 9)},
+		{[]stringset.Set{a, b, a, b}, nil},
+	}
+	for _, test := range tests {
+		got := stringset.SymDiffAll(test.sets...).Elements()
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("SymDiffAll(%v): got %+v, want %+v", test.sets, got, test.want)
+		}
+	}
+}
+
+func TestElementsBuf(t *testing.T) {
+	s := testSet(9, 3, 0, 6)
+	want := testKeys(0, 3, 6, 9)
+
+	if got := s.ElementsBuf(nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("ElementsBuf(nil): got %+v, want %+v", got, want)
+	}
+
+	buf := make([]string, 2, 10)
+	got := s.ElementsBuf(buf)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ElementsBuf(buf): got %+v, want %+v", got, want)
+	}
+	if &got[0] != &buf[:1][0] {
+		t.Error("ElementsBuf(buf) did not reuse the buffer storage")
+	}
+
+	if got := stringset.New().ElementsBuf(buf); len(got) != 0 {
+		t.Errorf("ElementsBuf on empty: got %+v, want empty", got)
+	}
+}
+
+func benchSet(n int) stringset.Set {
+	s := stringset.NewSize(n)
+	for i := 0; i < n; i++ {
+		s.Add(strconv.Itoa(i))
+	}
+	return s
+}
+
+func BenchmarkElements(b *testing.B) {
+	s := benchSet(1000)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = s.Elements()
+		}
+	})
+}
+
+func BenchmarkElementsBuf(b *testing.B) {
+	s := benchSet(1000)
+	pool := sync.Pool{New: func() interface{} { return new([]string) }}
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			buf := pool.Get().(*[]string)
+			*buf = s.ElementsBuf(*buf)
+			pool.Put(buf)
+		}
+	})
+}
+
+func TestZero(t *testing.T) {
+	var s stringset.Set
+	if s.HasZero() {
+		t.Error("HasZero on nil: got true, want false")
+	}
+	if s.DiscardZero() {
+		t.Error("DiscardZero on nil: got true, want false")
+	}
+
+	s = testSet(0, 1)
+	if s.HasZero() {
+		t.Errorf("%v.HasZero(): got true, want false", s)
+	}
+	s.Add("")
+	if !s.HasZero() {
+		t.Errorf("%v.HasZero(): got false, want true", s)
+	}
+	if !s.DiscardZero() {
+		t.Errorf("%v.DiscardZero(): got false, want true", s)
+	}
+	if s.HasZero() {
+		t.Errorf("%v.HasZero() after discard: got true, want false", s)
+	}
+	if s.DiscardZero() {
+		t.Errorf("%v.DiscardZero() after discard: got true, want false", s)
+	}
+	if want := testSet(0, 1); !s.Equals(want) {
+		t.Errorf("DiscardZero changed other elements: got %v, want %v", s, want)
+	}
+}
+
+func TestApply(t *testing.T) {
+	add := func(i int) stringset.Event { return stringset.Event{Op: stringset.OpAdd, Key: testValues[i]} }
+	rem := func(i int) stringset.Event { return stringset.Event{Op: stringset.OpRemove, Key: testValues[i]} }
+	tests := []struct {
+		before         stringset.Set
+		events         []stringset.Event
+		want           []string
+		added, removed int
+	}{
+		{nil, nil, nil, 0, 0},
+		{nil, []stringset.Event{rem(0)}, nil, 0, 0},
+		{nil, []stringset.Event{add(0), add(1)}, testKeys(0, 1), 2, 0},
+		{testSet(0), []stringset.Event{add(0), add(0), add(1)}, testKeys(0, 1), 1, 0},
+		{testSet(0, 1, 2), []stringset.Event{rem(1), rem(1), rem(3)}, testKeys(0, 2), 0, 1},
+		{testSet(0), []stringset.Event{add(1), rem(1), add(1), rem(0), rem(0)}, testKeys(1), 2, 2},
+		{testSet(4), []stringset.Event{{Op: 0, Key: testValues[5]}, {Op: 99, Key: testValues[4]}}, testKeys(4), 0, 0},
+	}
+	for _, test := range tests {
+		added, removed := test.before.Apply(test.events)
+		if got := test.before.Elements(); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Apply %+v: got %+v, want %+v", test.events, got, test.want)
+		}
+		if added != test.added || removed != test.removed {
+			t.Errorf("Apply %+v: got (%d, %d), want (%d, %d)",
+				test.events, added, removed, test.added, test.removed)
+		}
+	}
+}
+
+func TestEstimateUnion(t *testing.T) {
+	if got := stringset.EstimateUnion(); got != 0 {
+		t.Errorf("EstimateUnion(): got %d, want 0", got)
+	}
+	if got := stringset.EstimateUnion(nil, stringset.New()); got != 0 {
+		t.Errorf("EstimateUnion(ø, ø): got %d, want 0", got)
+	}
+	if got := stringset.EstimateUnion(testSet(0, 1, 2), testSet(2, 3)); got != 4 {
+		t.Errorf("EstimateUnion(small): got %d, want 4", got)
+	}
+
+	// Generate overlapping sets of various sizes: set i contains the numbers
+	// in [i*step, i*step+size), so consecutive sets overlap.
+	tests := []struct {
+		n, step, size int
+	}{
+		{1, 0, 1000},
+		{4, 500, 1000},
+		{10, 20000, 30000},
+		{3, 100000, 250000},
+	}
+	for _, test := range tests {
+		var sets []stringset.Set
+		var union stringset.Set
+		for i := 0; i < test.n; i++ {
+			s := stringset.NewSize(test.size)
+			for j := i * test.step; j < i*test.step+test.size; j++ {
+				s.Add(strconv.Itoa(j))
+			}
+			sets = append(sets, s)
+			union.Update(s)
+		}
+		got, want := float64(stringset.EstimateUnion(sets...)), float64(union.Len())
+		if diff := math.Abs(got-want) / want; diff > 0.03 {
+			t.Errorf("EstimateUnion(%d sets): got %v, want %v ± 3%% (error %.2f%%)", test.n, got, want, 100*diff)
+		} else {
+			t.Logf("EstimateUnion(%d sets): got %v, want %v (error %.2f%%)", test.n, got, want, 100*diff)
+		}
+	}
+}
+
+func TestStableElements(t *testing.T) {
+	if got := stringset.New().StableElements(); got != nil {
+		t.Errorf("StableElements on empty: got %+v, want nil", got)
+	}
+
+	s := stringset.New(testValues[:]...)
+	first := s.StableElements()
+	for i := 0; i < 10; i++ {
+		c := stringset.New()
+		for _, elt := range s.Unordered() {
+			c.Add(elt)
+		}
+		if got := c.StableElements(); !reflect.DeepEqual(got, first) {
+			t.Errorf("StableElements: got %+v, want %+v", got, first)
+		}
+	}
+	if got := stringset.New(first...); !got.Equals(s) {
+		t.Errorf("StableElements is not a permutation: got %v, want %v", got, s)
+	}
+}
+
+func TestIndicatorVector(t *testing.T) {
+	universe := testKeys(0, 1, 2, 3, 4)
+	tests := []struct {
+		input stringset.Set
+		want  []bool
+	}{
+		{nil, []bool{false, false, false, false, false}},
+		{testSet(0, 2), []bool{true, false, true, false, false}},
+		{testSet(1, 4, 7, 9), []bool{false, true, false, false, true}},
+		{testSet(5, 6), []bool{false, false, false, false, false}},
+		{stringset.New(testValues[:]...), []bool{true, true, true, true, true}},
+	}
+	for _, test := range tests {
+		if got := test.input.IndicatorVector(universe); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v.IndicatorVector(%+v): got %v, want %v", test.input, universe, got, test.want)
+		}
+	}
+	if got := testSet(0).IndicatorVector(nil); len(got) != 0 {
+		t.Errorf("IndicatorVector(nil): got %v, want empty", got)
+	}
+}
+
+func TestHammingDistance(t *testing.T) {
+	universe := testKeys(0, 1, 2, 3, 4, 5)
+	vec := func(s stringset.Set) []bool { return s.IndicatorVector(universe) }
+	tests := []struct {
+		a, b []bool
+		want int
+	}{
+		{nil, nil, 0},
+		{vec(nil), vec(nil), 0},
+		{vec(testSet(0, 1)), vec(testSet(0, 1)), 0},
+		{vec(testSet(0, 1)), vec(testSet(1, 2)), 2},
+		{vec(testSet(0, 1, 2)), vec(testSet(3, 4, 5)), 6},
+		{vec(testSet(0, 8, 9)), vec(testSet(0)), 0},
+		{[]bool{true, false}, []bool{true, false, true, false}, 1},
+		{[]bool{true, true, true}, nil, 3},
+	}
+	for _, test := range tests {
+		if got := stringset.HammingDistance(test.a, test.b); got != test.want {
+			t.Errorf("HammingDistance(%v, %v): got %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestShard(t *testing.T) {
+	byLength := func(s string) uint64 { return uint64(len(s)) }
+	in := stringset.New(testValues[:]...)
+
+	if got := in.Shard(0, nil); got != nil {
+		t.Errorf("Shard(0, nil): got %v, want nil", got)
+	}
+	got := in.Shard(3, byLength)
+	want := []stringset.Set{
+		stringset.New("one", "six", "ten", "two"),
+		stringset.New("four", "five", "nine"),
+		stringset.New("eight", "seven", "three"),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Shard(3, len): got %d shards, want %d", len(got), len(want))
+	}
+	for i, s := range got {
+		if !s.Equals(want[i]) {
+			t.Errorf("Shard(3, len) shard %d: got %v, want %v", i, s, want[i])
+		}
+	}
+
+	for _, n := range []int{1, 2, 3, 7, 16} {
+		shards := in.Shard(n, nil)
+		if len(shards) != n {
+			t.Errorf("Shard(%d, nil): got %d shards, want %d", n, len(shards), n)
+		}
+
+		// The shards must be disjoint and cover the input.
+		var all stringset.Set
+		total := 0
+		for _, s := range shards {
+			total += s.Len()
+			all.Update(s)
+		}
+		if total != in.Len() || !all.Equals(in) {
+			t.Errorf("Shard(%d, nil): shards %v do not partition %v", n, shards, in)
+		}
+
+		// Sharding is deterministic.
+		again := in.Shard(n, nil)
+		for i, s := range shards {
+			if !s.Equals(again[i]) {
+				t.Errorf("Shard(%d, nil) shard %d: got %v, then %v", n, i, s, again[i])
+			}
+		}
+	}
+}
+
+func TestShardSpread(t *testing.T) {
+	// Short keys that differ only in the high-order bits of their bytes must
+	// not collapse into a few shards with the default hash.  Unmixed FNV-1a
+	// puts each of these sets entirely into one shard.
+	var high stringset.Set
+	for c := 0x01; c < 0x100; c += 0x10 {
+		high.Add(string([]byte{byte(c)}))
+	}
+	vowels := stringset.New("a", "e", "i", "m", "q", "u", "y")
+	for _, s := range []stringset.Set{high, vowels} {
+		for _, n := range []int{2, 4} {
+			for i, shard := range s.Shard(n, nil) {
+				if shard.Empty() {
+					t.Errorf("Shard(%d, nil) of %v: shard %d is empty", n, s, i)
+				}
+			}
+		}
+	}
+}
+
+func TestShardSizes(t *testing.T) {
+	byLength := func(s string) uint64 { return uint64(len(s)) }
+	in := stringset.New(testValues[:]...)
+
+	if got := in.ShardSizes(0, nil); got != nil {
+		t.Errorf("ShardSizes(0, nil): got %v, want nil", got)
+	}
+	if got, want := in.ShardSizes(3, byLength), []int{4, 3, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ShardSizes(3, len): got %v, want %v", got, want)
+	}
+
+	big := benchSet(1000)
+	for _, s := range []stringset.Set{nil, in, big} {
+		for _, n := range []int{1, 2, 3, 7, 16} {
+			sizes := s.ShardSizes(n, nil)
+			if len(sizes) != n {
+				t.Errorf("ShardSizes(%d, nil): got %d sizes, want %d", n, len(sizes), n)
+			}
+
+			// The sizes must agree with Shard, and sum to the size of the input.
+			total := 0
+			for i, shard := range s.Shard(n, nil) {
+				if sizes[i] != shard.Len() {
+					t.Errorf("ShardSizes(%d, nil)[%d]: got %d, want %d", n, i, sizes[i], shard.Len())
+				}
+				total += sizes[i]
+			}
+			if total != s.Len() {
+				t.Errorf("ShardSizes(%d, nil): sizes %v sum to %d, want %d", n, sizes, total, s.Len())
+			}
+		}
+	}
+}
+
+func TestMergeSortedKeys(t *testing.T) {
+	a := testSet(0, 1, 2, 3, 4)
+	b := testSet(0, 4, 5, 6, 7)
+	c := testSet(3, 4, 8, 9)
+	tests := []struct {
+		sets []stringset.Set
+		want []string
+	}{
+		{nil, nil},
+		{[]stringset.Set{nil, stringset.New()}, nil},
+		{[]stringset.Set{a}, a.Elements()},
+		{[]stringset.Set{a, a}, a.Elements()},
+		{[]stringset.Set{nil, c, nil}, c.Elements()},
+		{[]stringset.Set{a, b}, testKeys(0, 1, 2, 3, 4, 5, 6, 7)},
+		{[]stringset.Set{c, b, a}, testValues[:]},
+		{[]stringset.Set{testSet(9), testSet(0), testSet(5)}, testKeys(0, 5, 9)},
+	}
+	for _, test := range tests {
+		got := stringset.MergeSortedKeys(test.sets...)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("MergeSortedKeys(%v): got %+v, want %+v", test.sets, got, test.want)
+		}
+	}
+}
+
+func benchSets(n, size int) []stringset.Set {
+	sets := make([]stringset.Set, n)
+	for i := range sets {
+		sets[i] = stringset.NewSize(size)
+		for j := 0; j < size; j++ {
+			sets[i].Add(strconv.Itoa(i*size/2 + j))
+		}
+	}
+	return sets
+}
+
+func BenchmarkMergeSortedKeys(b *testing.B) {
+	sets := benchSets(16, 10000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = stringset.MergeSortedKeys(sets...)
+	}
+}
+
+func BenchmarkUnionSortedKeys(b *testing.B) {
+	sets := benchSets(16, 10000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var u stringset.Set
+		for _, s := range sets {
+			u.Update(s)
+		}
+		_ = u.Elements()
+	}
+}
+
+func TestJournal(t *testing.T) {
+	add := func(i int) stringset.Change { return stringset.Change{Key: testValues[i], Added: true} }
+	rem := func(i int) stringset.Change { return stringset.Change{Key: testValues[i]} }
+	tests := []struct {
+		s1, s2 stringset.Set
+		want   []stringset.Change
+	}{
+		{nil, nil, nil},
+		{testSet(0, 1), testSet(1, 0), nil},
+		{nil, testSet(2, 0), []stringset.Change{add(0), add(2)}},
+		{testSet(3, 1), nil, []stringset.Change{rem(1), rem(3)}},
+		{testSet(0, 1, 2, 3), testSet(2, 3, 4, 5), []stringset.Change{rem(0), rem(1), add(4), add(5)}},
+		{testSet(9, 5, 1), testSet(8, 5, 0), []stringset.Change{add(0), rem(1), add(8), rem(9)}},
+	}
+	for _, test := range tests {
+		got := test.s1.Journal(test.s2)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v.Journal(%v): got %+v, want %+v", test.s1, test.s2, got, test.want)
+		}
+
+		// Applying the journal to s1 should yield s2.
+		s := test.s1.Clone()
+		for _, c := range got {
+			if c.Added {
+				s.Add(c.Key)
+			} else {
+				s.Discard(c.Key)
+			}
+		}
+		if !s.Equals(test.s2) {
+			t.Errorf("Applying %v.Journal(%v): got %v, want %v", test.s1, test.s2, s, test.s2)
+		}
+	}
+}
+
+func TestPartitionByScoreNaN(t *testing.T) {
+	// Elements containing "e" have a NaN score; the rest score by length.
+	score := func(s string) float64 {
+		if strings.Contains(s, "e") {
+			return math.NaN()
+		}
+		return float64(len(s))
+	}
+	in := stringset.New("a", "bb", "ccc", "e", "ee", "xe", "dddd")
+	want := []stringset.Set{
+		stringset.New("a", "bb", "ccc"),
+		stringset.New("dddd", "e"),
+		stringset.New("ee", "xe"),
+	}
+
+	// Repeat, since an inconsistent ordering depends on map iteration order.
+	for i := 0; i < 20; i++ {
+		for j, b := range in.PartitionByScore(score, 3) {
+			if !b.Equals(want[j]) {
+				t.Fatalf("PartitionByScore(nan, 3) bucket %d: got %v, want %v", j, b, want[j])
+			}
+		}
+	}
+}
+
+func TestTopKNaN(t *testing.T) {
+	// Elements containing "e" have a NaN score; the rest score by length.
+	score := func(s string) float64 {
+		if strings.Contains(s, "e") {
+			return math.NaN()
+		}
+		return float64(len(s))
+	}
+	in := stringset.New("a", "bb", "ccc", "e", "ee", "xe", "dddd")
+
+	// Repeat, since an inconsistent ordering depends on map iteration order.
+	for i := 0; i < 20; i++ {
+		if got, want := in.TopK(5, score), []string{"dddd", "ccc", "bb", "a", "e"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("TopK(nan, 5): got %q, want %q", got, want)
+		}
+		if got, want := in.TopK(7, score), []string{"dddd", "ccc", "bb", "a", "e", "ee", "xe"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("TopK(nan, 7): got %q, want %q", got, want)
+		}
+	}
+}
+
+func TestTopK(t *testing.T) {
+	byLength := func(s string) float64 { return float64(len(s)) }
+	in := stringset.New(testValues[:]...)
+	tests := []struct {
+		input stringset.Set
+		k     int
+		want  []string
+	}{
+		{nil, 3, nil},
+		{in, 0, nil},
+		{in, -2, nil},
+		{in, 1, []string{"eight"}},
+		{in, 3, []string{"eight", "seven", "three"}},
+		{in, 5, []string{"eight", "seven", "three", "five", "four"}},
+		{in, 10, []string{"eight", "seven", "three", "five", "four", "nine", "one", "six", "ten", "two"}},
+		{in, 25, []string{"eight", "seven", "three", "five", "four", "nine", "one", "six", "ten", "two"}},
+		{testSet(4, 9), 5, []string{"one", "two"}},
+	}
+	for _, test := range tests {
+		got := test.input.TopK(test.k, byLength)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v.TopK(%d, len): got %+q, want %+q", test.input, test.k, got, test.want)
+		}
+	}
+}
+
+func TestOverlapsAtLeast(t *testing.T) {
+	nat := stringset.New(testValues[:]...)
+	a := testSet(0, 1, 2, 3)
+	b := testSet(2, 3, 4, 5, 6, 7)
+	tests := []struct {
+		s1, s2 stringset.Set
+		frac   float64
+		want   bool
+	}{
+		// Empty sets.
+		{nil, nil, 1, true},
+		{nil, nil, 1.5, false},
+		{nil, a, 0, true},
+		{a, nil, 0.1, false},
+
+		// |a ∩ b| = 2, min = 4, coefficient 0.5.
+		{a, b, 0.25, true},
+		{a, b, 0.5, true},
+		{b, a, 0.5, true},
+		{a, b, 0.51, false},
+		{a, b, 1, false},
+
+		// Subsets have coefficient 1.
+		{a, nat, 1, true},
+		{nat, b, 1, true},
+		{testSet(0, 1), testSet(8, 9), 0, true},
+		{testSet(0, 1), testSet(8, 9), 0.01, false},
+	}
+	for _, test := range tests {
+		if got := test.s1.OverlapsAtLeast(test.s2, test.frac); got != test.want {
+			t.Errorf("%v.OverlapsAtLeast(%v, %v): got %v, want %v", test.s1, test.s2, test.frac, got, test.want)
+		}
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("STRINGSET_TEST_FLAG_ALPHA", "on")
+	t.Setenv("STRINGSET_TEST_FLAG_BETA", "off")
+	t.Setenv("STRINGSET_TEST_FLAG_GAMMA", "on")
+	t.Setenv("STRINGSET_TEST_OTHER", "x=y")
+
+	tests := []struct {
+		pattern string
+		values  bool
+		want    stringset.Set
+	}{
+		{`^STRINGSET_TEST_FLAG_`, false, stringset.New(
+			"STRINGSET_TEST_FLAG_ALPHA", "STRINGSET_TEST_FLAG_BETA", "STRINGSET_TEST_FLAG_GAMMA",
+		)},
+		{`^STRINGSET_TEST_FLAG_`, true, stringset.New("on", "off")},
+		{`^STRINGSET_TEST_OTHER$`, true, stringset.New("x=y")},
+		{`^STRINGSET_TEST_NONESUCH$`, false, nil},
+	}
+	for _, test := range tests {
+		got := stringset.FromEnv(regexp.MustCompile(test.pattern), test.values)
+		if !got.Equals(test.want) {
+			t.Errorf("FromEnv(%q, %v): got %v, want %v", test.pattern, test.values, got, test.want)
+		}
+	}
+
+	if got := stringset.FromEnv(nil, false); !got.Contains("STRINGSET_TEST_OTHER", "STRINGSET_TEST_FLAG_BETA") {
+		t.Errorf("FromEnv(nil, false): got %v, missing test variables", got)
+	}
+}
+
+func TestCountWhere(t *testing.T) {
+	in := stringset.New(testValues[:]...)
+	isPalindrome := func(s string) bool {
+		for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+			if s[i] != s[j] {
+				return false
+			}
+		}
+		return true
+	}
+	tests := []struct {
+		input stringset.Set
+		f     func(string) bool
+		want  int
+	}{
+		{nil, isPalindrome, 0},
+		{in, isPalindrome, 0},
+		{stringset.New("abba", "a", "ab", "level", "xyz"), isPalindrome, 3},
+		{in, func(s string) bool { return len(s) == 3 }, 4},
+		{in, func(string) bool { return true }, 10},
+	}
+	for _, test := range tests {
+		if got := test.input.CountWhere(test.f); got != test.want {
+			t.Errorf("%v.CountWhere(f): got %d, want %d", test.input, got, test.want)
+		}
+		if got := test.input.Count(test.f); got != test.want {
+			t.Errorf("%v.Count(f): got %d, want %d", test.input, got, test.want)
+		}
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	byLength := func(s string) int { return len(s) }
+	tests := []struct {
+		input stringset.Set
+		want  map[int]int
+	}{
+		{nil, nil},
+		{stringset.New(), nil},
+		{stringset.New(testValues[:]...), map[int]int{3: 4, 4: 3, 5: 3}},
+		{stringset.New("", "a", "b", "cd"), map[int]int{0: 1, 1: 2, 2: 1}},
+	}
+	for _, test := range tests {
+		if got := test.input.Histogram(byLength); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v.Histogram(len): got %v, want %v", test.input, got, test.want)
+		}
+	}
+}
+
+func TestGzipRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	large := stringset.NewSize(50000)
+	for large.Len() < 50000 {
+		large.Add(strconv.FormatUint(rng.Uint64(), 36))
+	}
+
+	tests := []stringset.Set{
+		nil,
+		stringset.New(""),
+		stringset.New("", "a"),
+		stringset.New(testValues[:]...),
+		stringset.New("tab\there", "space here", "ünïcödé"),
+		large,
+	}
+	for _, s := range tests {
+		data, err := s.MarshalGzip()
+		if err != nil {
+			t.Errorf("MarshalGzip %v: unexpected error: %v", s, err)
+			continue
+		}
+		got, err := stringset.UnmarshalGzip(data)
+		if err != nil {
+			t.Errorf("UnmarshalGzip: unexpected error: %v", err)
+		} else if !got.Equals(s) {
+			t.Errorf("UnmarshalGzip: got %d elements, want %v (%d elements)", got.Len(), s, s.Len())
+		}
+		if s.Empty() && got != nil {
+			t.Errorf("UnmarshalGzip of empty set: got %#v, want nil", got)
+		}
+	}
+}
+
+func TestGzipErrors(t *testing.T) {
+	if data, err := stringset.New("ok", "bad\nelement").MarshalGzip(); err == nil {
+		t.Errorf("MarshalGzip with newline: got %q, want error", data)
+	}
+	if got, err := stringset.UnmarshalGzip([]byte("not gzip data")); err == nil {
+		t.Errorf("UnmarshalGzip of bad data: got %v, want error", got)
+	}
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write([]byte("a\nb\nunterminated"))
+	w.Close()
+	if got, err := stringset.UnmarshalGzip(buf.Bytes()); err == nil {
+		t.Errorf("UnmarshalGzip of unterminated input: got %v, want error", got)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	nat := stringset.New(testValues[:]...)
+	tests := []struct {
+		s1, s2        stringset.Set
+		jaccard, dice float64
+	}{
+		{nil, nil, 1, 1},
+		{nil, stringset.New(), 1, 1},
+		{nil, testSet(0), 0, 0},
+		{nat, nat, 1, 1},
+		{testSet(0, 1), testSet(1, 0), 1, 1},
+		{testSet(0, 1), testSet(2, 3), 0, 0},
+		{testSet(0, 1, 2), testSet(1, 2, 3), 0.5, 2.0 / 3},
+		{testSet(0, 1, 2, 3), testSet(0), 0.25, 0.4},
+		{nat, testSet(0, 1, 2, 3, 4), 0.5, 2.0 / 3},
+	}
+	for _, test := range tests {
+		if got := test.s1.JaccardSimilarity(test.s2); math.Abs(got-test.jaccard) > 1e-9 {
+			t.Errorf("%v.JaccardSimilarity(%v): got %v, want %v", test.s1, test.s2, got, test.jaccard)
+		}
+		if got := test.s2.JaccardSimilarity(test.s1); math.Abs(got-test.jaccard) > 1e-9 {
+			t.Errorf("%v.JaccardSimilarity(%v): got %v, want %v", test.s2, test.s1, got, test.jaccard)
+		}
+		if got := test.s1.DiceSimilarity(test.s2); math.Abs(got-test.dice) > 1e-9 {
+			t.Errorf("%v.DiceSimilarity(%v): got %v, want %v", test.s1, test.s2, got, test.dice)
+		}
+		if got := test.s2.DiceSimilarity(test.s1); math.Abs(got-test.dice) > 1e-9 {
+			t.Errorf("%v.DiceSimilarity(%v): got %v, want %v", test.s2, test.s1, got, test.dice)
+		}
+	}
+}
+
+func TestClosest(t *testing.T) {
+	s := testSet(0, 1, 2, 3)
+	tests := []struct {
+		input      stringset.Set
+		candidates []stringset.Set
+		want       int
+		sim        float64
+	}{
+		{s, nil, -1, 0},
+		{nil, nil, -1, 0},
+		{nil, []stringset.Set{testSet(0), nil}, 1, 1},
+		{s, []stringset.Set{nil}, 0, 0},
+		{s, []stringset.Set{testSet(5, 6), testSet(7)}, 0, 0},
+		{s, []stringset.Set{testSet(0), testSet(0, 1), testSet(0, 1, 2, 9)}, 2, 3.0 / 5},
+		{s, []stringset.Set{testSet(4, 5), testSet(0, 1, 2, 3), testSet(0, 1, 2)}, 1, 1},
+		{s, []stringset.Set{testSet(8), testSet(0, 1), testSet(2, 3), testSet(1, 2)}, 1, 0.5},
+		{s, []stringset.Set{testSet(0, 1, 2, 3, 4, 5, 6, 7), testSet(3)}, 0, 0.5},
+	}
+	for _, test := range tests {
+		got, sim := test.input.Closest(test.candidates...)
+		if got != test.want || math.Abs(sim-test.sim) > 1e-9 {
+			t.Errorf("%v.Closest(%v): got (%d, %v), want (%d, %v)",
+				test.input, test.candidates, got, sim, test.want, test.sim)
+		}
+	}
+}
+
+// hasSink prevents the compiler from optimizing away membership checks in the
+// benchmarks below.
+var hasSink bool
+
+func BenchmarkHas(b *testing.B) {
+	s := benchSet(1000)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		hasSink = s.Has("500")
+	}
+}
+
+func BenchmarkContainsOne(b *testing.B) {
+	s := benchSet(1000)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		hasSink = s.Contains("500")
+	}
+}
+
+func BenchmarkContainsFunc(b *testing.B) {
+	bools := make(map[string]bool)
+	ints := make(map[string]int)
+	for k := range benchSet(1000) {
+		bools[k] = true
+		ints[k] = len(k)
+	}
+	for _, bench := range []struct {
+		name     string
+		contains func(interface{}, string) bool
+		input    interface{}
+	}{
+		{"Bool", stringset.Contains, bools},
+		{"BoolReflect", stringset.ContainsReflect, bools},
+		{"Int", stringset.Contains, ints},
+		{"IntReflect", stringset.ContainsReflect, ints},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				hasSink = bench.contains(bench.input, "500")
+			}
+		})
+	}
+}
+
+func TestExclusives(t *testing.T) {
+	a := testSet(0, 1, 2, 3, 4)
+	b := testSet(0, 4, 5, 6, 7)
+	c := testSet(3, 4, 8, 9)
+	tests := []struct {
+		sets []stringset.Set
+		want []stringset.Set
+	}{
+		{nil, []stringset.Set{}},
+		{[]stringset.Set{nil}, []stringset.Set{nil}},
+		{[]stringset.Set{a}, []stringset.Set{a}},
+		{[]stringset.Set{a, a}, []stringset.Set{nil, nil}},
+		{[]stringset.Set{a, b}, []stringset.Set{testSet(1, 2, 3), testSet(5, 6, 7)}},
+		{[]stringset.Set{a, b, c}, []stringset.Set{testSet(1, 2), testSet(5, 6, 7), testSet(8, 9)}},
+		{[]stringset.Set{c, nil, a}, []stringset.Set{testSet(8, 9), nil, testSet(0, 1, 2)}},
+	}
+	for _, test := range tests {
+		got := stringset.Exclusives(test.sets...)
+		if len(got) != len(test.want) {
+			t.Errorf("Exclusives(%v): got %d results, want %d", test.sets, len(got), len(test.want))
+			continue
+		}
+		for i, s := range got {
+			if !s.Equals(test.want[i]) {
+				t.Errorf("Exclusives(%v)[%d]: got %v, want %v", test.sets, i, s, test.want[i])
+			}
+		}
+	}
+}
+
+func TestCoreShell(t *testing.T) {
+	a := testSet(0, 1, 2, 3, 4)
+	b := testSet(0, 4, 5, 6, 7)
+	c := testSet(0, 3, 4, 8, 9)
+	tests := []struct {
+		sets        []stringset.Set
+		core, shell stringset.Set
+	}{
+		{nil, nil, nil},
+		{[]stringset.Set{nil, nil}, nil, nil},
+		{[]stringset.Set{a}, a, nil},
+		{[]stringset.Set{a, a}, a, nil},
+		{[]stringset.Set{a, nil}, nil, a},
+		{[]stringset.Set{a, b}, testSet(0, 4), testSet(1, 2, 3, 5, 6, 7)},
+		{[]stringset.Set{a, b, c}, testSet(0, 4), testSet(1, 2, 3, 5, 6, 7, 8, 9)},
+		{[]stringset.Set{testSet(1), testSet(2)}, nil, testSet(1, 2)},
+	}
+	for _, test := range tests {
+		core, shell := stringset.CoreShell(test.sets...)
+		if !core.Equals(test.core) {
+			t.Errorf("CoreShell(%v) core: got %v, want %v", test.sets, core, test.core)
+		}
+		if !shell.Equals(test.shell) {
+			t.Errorf("CoreShell(%v) shell: got %v, want %v", test.sets, shell, test.shell)
+		}
+		if core.Intersects(shell) {
+			t.Errorf("CoreShell(%v): core %v and shell %v are not disjoint", test.sets, core, shell)
+		}
+		var union stringset.Set
+		for _, s := range test.sets {
+			union.Update(s)
+		}
+		if got := core.Union(shell); !got.Equals(union) {
+			t.Errorf("CoreShell(%v): core ∪ shell = %v, want %v", test.sets, got, union)
+		}
+	}
+}
+
+func TestRename(t *testing.T) {
+	in := stringset.New("a", "b", "c")
+	tests := []struct {
+		input stringset.Set
+		m     map[string]string
+		want  stringset.Set
+	}{
+		{nil, nil, nil},
+		{nil, map[string]string{"a": "x"}, nil},
+		{in, nil, in},
+		{in, map[string]string{"a": "x"}, stringset.New("x", "b", "c")},
+		{in, map[string]string{"q": "x", "r": "a"}, in},                      // absent keys
+		{in, map[string]string{"a": "x", "b": "x"}, stringset.New("x", "c")}, // collide
+		{in, map[string]string{"a": "c"}, stringset.New("b", "c")},           // collide with existing
+		{in, map[string]string{"a": "b", "b": "a"}, in},                      // swap
+		{in, map[string]string{"a": "b", "b": "c", "c": "d"}, stringset.New("b", "c", "d")},
+	}
+	for _, test := range tests {
+		got := test.input.Rename(test.m)
+		if !got.Equals(test.want) {
+			t.Errorf("%v.Rename(%v): got %v, want %v", test.input, test.m, got, test.want)
+		}
+	}
+	if !in.Equals(stringset.New("a", "b", "c")) {
+		t.Errorf("Rename modified its receiver: %v", in)
+	}
+}
+
+func TestContainsOr(t *testing.T) {
+	defaults := testSet(0, 1)
+	tests := []struct {
+		s, fallback stringset.Set
+		x           string
+		want        bool
+	}{
+		{nil, nil, testValues[0], false},
+		{nil, defaults, testValues[0], true},
+		{nil, defaults, testValues[2], false},
+		{stringset.New(), defaults, testValues[1], true},
+		{stringset.New(), defaults, testValues[3], false},
+		{testSet(2), defaults, testValues[2], true},
+		{testSet(2), defaults, testValues[0], false}, // fallback not consulted
+		{testSet(2), nil, testValues[2], true},
+	}
+	for _, test := range tests {
+		if got := test.s.ContainsOr(test.x, test.fallback); got != test.want {
+			t.Errorf("%v.ContainsOr(%q, %v): got %v, want %v", test.s, test.x, test.fallback, got, test.want)
+		}
+	}
+}
+
+func TestSplitStable(t *testing.T) {
+	byLength := func(s string) uint64 { return uint64(len(s)) }
+	in := stringset.New(testValues[:]...)
+
+	a, b := in.SplitStable(byLength)
+	if want := stringset.New("four", "five", "nine"); !a.Equals(want) {
+		t.Errorf("SplitStable(len) a: got %v, want %v", a, want)
+	}
+	if want := stringset.New("one", "six", "ten", "two", "eight", "seven", "three"); !b.Equals(want) {
+		t.Errorf("SplitStable(len) b: got %v, want %v", b, want)
+	}
+
+	if a, b := stringset.New().SplitStable(nil); a != nil || b != nil {
+		t.Errorf("SplitStable on empty: got %v, %v; want nil, nil", a, b)
+	}
+
+	big := benchSet(1000)
+	a, b = big.SplitStable(nil)
+	if a.Intersects(b) {
+		t.Errorf("SplitStable(nil): halves are not disjoint: %v", a.Intersect(b))
+	}
+	if !a.Union(b).Equals(big) {
+		t.Errorf("SplitStable(nil): halves do not cover the input")
+	}
+	if a.Len() < 400 || b.Len() < 400 {
+		t.Errorf("SplitStable(nil): unbalanced split %d/%d", a.Len(), b.Len())
+	}
+	a2, b2 := big.SplitStable(nil)
+	if !a2.Equals(a) || !b2.Equals(b) {
+		t.Error("SplitStable(nil) is not deterministic")
+	}
+
+	// Anagrams, and strings differing only in even-valued byte changes, must
+	// not all fall on the same side.  Unmixed FNV-1a splits by the parity of
+	// the low bits of the bytes, so it puts each of these sets on one side.
+	for _, s := range []stringset.Set{
+		stringset.New("abc", "acb", "bac", "bca", "cab", "cba"),
+		stringset.New("a", "c", "e", "g", "i", "k", "m", "o"),
+		stringset.New("xa", "xc", "xe", "xg", "ya", "yc", "ye", "yg"),
+	} {
+		if a, b := s.SplitStable(nil); a.Empty() || b.Empty() {
+			t.Errorf("SplitStable(nil) of %v: got %v, %v; want both non-empty", s, a, b)
+		}
+	}
+}
+
+func TestSelectGlob(t *testing.T) {
+	in := stringset.New("main.go", "main_test.go", "README.md", "lib/util.go", "go.mod")
+	tests := []struct {
+		input    stringset.Set
+		patterns []string
+		want     stringset.Set
+	}{
+		{nil, []string{"*.go"}, nil},
+		{in, nil, nil},
+		{in, []string{"*.go"}, stringset.New("main.go", "main_test.go")},
+		{in, []string{"*/*.go"}, stringset.New("lib/util.go")},
+		{in, []string{"*.txt"}, nil},
+		{in, []string{"go.[a-z]*", "*.md"}, stringset.New("go.mod", "README.md")},
+		{in, []string{"*_test.go", "main*"}, stringset.New("main.go", "main_test.go")},
+		{in, []string{"lib[/]*"}, stringset.New("lib/util.go")},
+		{in, []string{`lib\/*.go`}, stringset.New("lib/util.go")},
+	}
+	for _, test := range tests {
+		got, err := test.input.SelectGlobs(test.patterns...)
+		if err != nil {
+			t.Errorf("%v.SelectGlobs(%q): unexpected error: %v", test.input, test.patterns, err)
+		} else if !got.Equals(test.want) {
+			t.Errorf("%v.SelectGlobs(%q): got %v, want %v", test.input, test.patterns, got, test.want)
+		}
+		if len(test.patterns) == 1 {
+			got, err := test.input.SelectGlob(test.patterns[0])
+			if err != nil || !got.Equals(test.want) {
+				t.Errorf("%v.SelectGlob(%q): got %v, %v; want %v", test.input, test.patterns[0], got, err, test.want)
+			}
+		}
+	}
+
+	for _, bad := range []string{"[", "*.[go", `\`, "a/*/[z-", "x/[", "[a/b"} {
+		for _, s := range []stringset.Set{in, nil} {
+			if got, err := s.SelectGlob(bad); !errors.Is(err, filepath.ErrBadPattern) {
+				t.Errorf("%v.SelectGlob(%q): got %v, %v; want ErrBadPattern", s, bad, got, err)
+			}
+		}
+		if got, err := in.SelectGlobs("*.go", bad); !errors.Is(err, filepath.ErrBadPattern) {
+			t.Errorf("SelectGlobs(*.go, %q): got %v, %v; want ErrBadPattern", bad, got, err)
+		}
+	}
+}
+
+func TestDiffMasked(t *testing.T) {
+	nat := stringset.New(testValues[:]...)
+	odd := testSet(1, 3, 5, 7, 9)
+	prime := testSet(2, 3, 5, 7)
+	mask := testSet(0, 8)
+	tests := []struct {
+		s, s2, mask stringset.Set
+	}{
+		{nil, nil, nil},
+		{nat, nil, nil},
+		{nat, odd, nil},
+		{nat, nil, mask},
+		{nat, odd, mask},
+		{nat, prime, mask},
+		{odd, prime, mask},
+		{prime, nat, mask},
+		{mask, odd, mask},
+	}
+	for _, test := range tests {
+		got := test.s.DiffMasked(test.s2, test.mask)
+		want := test.s.Diff(test.s2).Diff(test.mask)
+		if !got.Equals(want) {
+			t.Errorf("%v.DiffMasked(%v, %v): got %v, want %v", test.s, test.s2, test.mask, got, want)
+		}
+	}
+}
+
+func TestRegexp(t *testing.T) {
+	in := stringset.New("a1", "b15", "c9", "q", "xa1")
+	tests := []struct {
+		input   stringset.Set
+		pattern string
+		want    stringset.Set
+	}{
+		{nil, `.*`, nil},
+		{stringset.New(), `a`, nil},
+		{in, `[a-z]\d+`, stringset.New("a1", "b15", "c9", "xa1")},
+		{in, `^[a-z]\d+$`, stringset.New("a1", "b15", "c9")},
+		{in, `a1`, stringset.New("a1", "xa1")},
+		{in, `^a1$`, stringset.New("a1")},
+		{in, `^$`, nil},
+		{in, ``, in},
+	}
+	for _, test := range tests {
+		re := regexp.MustCompile(test.pattern)
+		if got := test.input.SelectRegexp(re); !got.Equals(test.want) {
+			t.Errorf("%v.SelectRegexp(%q): got %v, want %v", test.input, test.pattern, got, test.want)
+		}
+
+		s := test.input.Clone()
+		wantRest := test.input.Diff(test.want)
+		if n := s.DiscardRegexp(re); n != test.want.Len() {
+			t.Errorf("%v.DiscardRegexp(%q): removed %d, want %d", test.input, test.pattern, n, test.want.Len())
+		}
+		if !s.Equals(wantRest) {
+			t.Errorf("%v.DiscardRegexp(%q): got %v, want %v", test.input, test.pattern, s, wantRest)
+		}
+	}
+}
+
+func TestUnionValues(t *testing.T) {
+	tests := []struct {
+		input map[string]stringset.Set
+		want  stringset.Set
+	}{
+		{nil, nil},
+		{map[string]stringset.Set{}, nil},
+		{map[string]stringset.Set{"a": nil, "b": stringset.New()}, nil},
+		{map[string]stringset.Set{"a": testSet(0, 1)}, testSet(0, 1)},
+		{map[string]stringset.Set{"a": testSet(0, 1), "b": nil, "c": testSet(1, 2)}, testSet(0, 1, 2)},
+		{map[string]stringset.Set{
+			"x": testSet(0, 1, 2, 3),
+			"y": testSet(2, 3, 4),
+			"z": testSet(4, 9),
+		}, testSet(0, 1, 2, 3, 4, 9)},
+	}
+	for _, test := range tests {
+		got := stringset.UnionValues(test.input)
+		if !got.Equals(test.want) {
+			t.Errorf("UnionValues(%v): got %v, want %v", test.input, got, test.want)
+		}
+		if test.want == nil && got != nil {
+			t.Errorf("UnionValues(%v): got %#v, want nil", test.input, got)
+		}
+	}
+}
+
+func TestJoin(t *testing.T) {
+	tests := []struct {
+		input        stringset.Set
+		sep          string
+		join, quoted string
+	}{
+		{nil, ", ", "", ""},
+		{stringset.New(), ",", "", ""},
+		{stringset.New(""), ",", "", `""`},
+		{stringset.New("a"), ", ", "a", `"a"`},
+		{stringset.New("c", "a", "b"), ", ", "a, b, c", `"a", "b", "c"`},
+		{stringset.New("b", "a"), "", "ab", `"a""b"`},
+		{stringset.New("x,y", "z"), ",", "x,y,z", `"x,y","z"`},
+		{stringset.New("tab\t", `q"`), " ", "q\" tab\t", `"q\"" "tab\t"`},
+	}
+	for _, test := range tests {
+		if got := test.input.Join(test.sep); got != test.join {
+			t.Errorf("%v.Join(%q): got %q, want %q", test.input, test.sep, got, test.join)
+		}
+		if got := test.input.JoinQuoted(test.sep); got != test.quoted {
+			t.Errorf("%v.JoinQuoted(%q): got %q, want %q", test.input, test.sep, got, test.quoted)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		input stringset.Set
+		want  string
+	}{
+		{nil, "ø"},
+		{stringset.New(), "ø"},
+		{stringset.New(""), `{""}`},
+		{stringset.New("b", "a"), `{"a", "b"}`},
+		{stringset.New("x\ny"), `{"x\ny"}`},
+	}
+	for _, test := range tests {
+		if got := test.input.String(); got != test.want {
+			t.Errorf("String(): got %s, want %s", got, test.want)
+		}
+	}
+}
+
+// naturalLess orders strings so that runs of decimal digits compare by their
+// numeric value, e.g., "item2" < "item10".
+func naturalLess(a, b string) bool {
+	isDigit := func(c byte) bool { return '0' <= c && c <= '9' }
+	for a != "" && b != "" {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			i, j := 0, 0
+			for i < len(a) && isDigit(a[i]) {
+				i++
+			}
+			for j < len(b) && isDigit(b[j]) {
+				j++
+			}
+			na, _ := strconv.Atoi(a[:i])
+			nb, _ := strconv.Atoi(b[:j])
+			if na != nb {
+				return na < nb
+			}
+			a, b = a[i:], b[j:]
+		} else if a[0] != b[0] {
+			return a[0] < b[0]
+		} else {
+			a, b = a[1:], b[1:]
+		}
+	}
+	return len(a) < len(b)
+}
+
+func TestElementsFunc(t *testing.T) {
+	items := stringset.New("item10", "item2", "item1", "item20", "item3", "box7", "box07x")
+	tests := []struct {
+		input stringset.Set
+		less  func(a, b string) bool
+		want  []string
+	}{
+		{nil, nil, nil},
+		{nil, naturalLess, nil},
+		{items, nil, []string{"box07x", "box7", "item1", "item10", "item2", "item20", "item3"}},
+		{items, naturalLess, []string{"box7", "box07x", "item1", "item2", "item3", "item10", "item20"}},
+		{testSet(0, 1, 2), func(a, b string) bool { return a > b }, testKeys(2, 1, 0)},
+	}
+	for _, test := range tests {
+		if got := test.input.ElementsFunc(test.less); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v.ElementsFunc: got %q, want %q", test.input, got, test.want)
+		}
+	}
+}
+
+func TestStringFunc(t *testing.T) {
+	tests := []struct {
+		input stringset.Set
+		less  func(a, b string) bool
+		want  string
+	}{
+		{nil, nil, "ø"},
+		{nil, naturalLess, "ø"},
+		{stringset.New("b", "a"), nil, `{"a", "b"}`},
+		{stringset.New("a10", "a9", "a1"), nil, `{"a1", "a10", "a9"}`},
+		{stringset.New("a10", "a9", "a1"), naturalLess, `{"a1", "a9", "a10"}`},
+	}
+	for _, test := range tests {
+		if got := test.input.StringFunc(test.less); got != test.want {
+			t.Errorf("StringFunc(): got %s, want %s", got, test.want)
+		}
+	}
+}
+
+func TestIntersectValues(t *testing.T) {
+	tests := []struct {
+		input map[string]stringset.Set
+		want  stringset.Set
+	}{
+		{nil, nil},
+		{map[string]stringset.Set{}, nil},
+		{map[string]stringset.Set{"a": testSet(0, 1)}, testSet(0, 1)},
+		{map[string]stringset.Set{"a": testSet(0, 1), "b": testSet(1, 2)}, testSet(1)},
+		{map[string]stringset.Set{"a": testSet(0, 1), "b": testSet(2, 3)}, nil},
+		{map[string]stringset.Set{"a": testSet(0, 1, 2), "b": nil, "c": testSet(1, 2)}, nil},
+		{map[string]stringset.Set{"a": testSet(0, 1, 2), "b": stringset.New()}, nil},
+		{map[string]stringset.Set{
+			"x": testSet(0, 1, 2, 3, 4, 5),
+			"y": testSet(2, 3, 4, 9),
+			"z": testSet(4, 3, 8),
+		}, testSet(3, 4)},
+	}
+	for _, test := range tests {
+		got := stringset.IntersectValues(test.input)
+		if !got.Equals(test.want) {
+			t.Errorf("IntersectValues(%v): got %v, want %v", test.input, got, test.want)
+		}
+		if test.want == nil && got != nil {
+			t.Errorf("IntersectValues(%v): got %#v, want nil", test.input, got)
+		}
+	}
+}
+
+func TestEqualsMap(t *testing.T) {
+	tests := []struct {
+		s    stringset.Set
+		m    map[string]struct{}
+		want bool
+	}{
+		{nil, nil, true},
+		{nil, map[string]struct{}{}, true},
+		{testSet(0), nil, false},
+		{nil, map[string]struct{}{testValues[0]: {}}, false},
+		{testSet(0, 1), map[string]struct{}{testValues[1]: {}, testValues[0]: {}}, true},
+		{testSet(0, 1), map[string]struct{}{testValues[1]: {}, testValues[2]: {}}, false},
+		{testSet(0, 1), map[string]struct{}{testValues[1]: {}}, false},
+	}
+	for _, test := range tests {
+		if got := test.s.EqualsMap(test.m); got != test.want {
+			t.Errorf("%v.EqualsMap(%v): got %v, want %v", test.s, test.m, got, test.want)
+		}
+	}
+}
+
+func TestEqualsBoolMap(t *testing.T) {
+	a, b, c := testValues[0], testValues[1], testValues[2]
+	tests := []struct {
+		s    stringset.Set
+		m    map[string]bool
+		want bool
+	}{
+		{nil, nil, true},
+		{nil, map[string]bool{a: false, b: false}, true},
+		{nil, map[string]bool{a: true}, false},
+		{testSet(0), nil, false},
+		{testSet(0, 1), map[string]bool{a: true, b: true}, true},
+		{testSet(0, 1), map[string]bool{a: true, b: true, c: false}, true}, // false entries are absent
+		{testSet(0), map[string]bool{a: true, b: false, c: false}, true},
+		{testSet(0, 1), map[string]bool{a: true, b: false, c: true}, false},
+		{testSet(0, 1), map[string]bool{a: true, b: false}, false},
+		{testSet(0, 1, 2), map[string]bool{a: true, b: true}, false},
+	}
+	for _, test := range tests {
+		if got := test.s.EqualsBoolMap(test.m); got != test.want {
+			t.Errorf("%v.EqualsBoolMap(%v): got %v, want %v", test.s, test.m, got, test.want)
+		}
+	}
+}
+
+func TestMapErr(t *testing.T) {
+	parse := func(s string) (string, error) {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return "", err
+		}
+		return strconv.Itoa(n), nil
+	}
+	tests := []struct {
+		input   stringset.Set
+		want    stringset.Set
+		wantErr []string
+	}{
+		{nil, nil, nil},
+		{stringset.New("1", "02", "3"), stringset.New("1", "2", "3"), nil},
+		{stringset.New("01", "1", "x", "2", "y"), stringset.New("1", "2"), []string{
+			`element "x": strconv.Atoi: parsing "x": invalid syntax`,
+			`element "y": strconv.Atoi: parsing "y": invalid syntax`,
+		}},
+		{stringset.New("a", "b"), nil, []string{
+			`element "a": strconv.Atoi: parsing "a": invalid syntax`,
+			`element "b": strconv.Atoi: parsing "b": invalid syntax`,
+		}},
+	}
+	for _, test := range tests {
+		got, errs := test.input.MapErr(parse)
+		if !got.Equals(test.want) {
+			t.Errorf("%v.MapErr(parse): got %v, want %v", test.input, got, test.want)
+		}
+		var msgs []string
+		for _, err := range errs {
+			msgs = append(msgs, err.Error())
+			if !errors.Is(err, strconv.ErrSyntax) {
+				t.Errorf("%v.MapErr(parse): error %v does not wrap the original", test.input, err)
+			}
+		}
+		if !reflect.DeepEqual(msgs, test.wantErr) {
+			t.Errorf("%v.MapErr(parse) errors:\n got %q\nwant %q", test.input, msgs, test.wantErr)
+		}
+	}
+}
+
+func TestCommonPrefix(t *testing.T) {
+	tests := []struct {
+		input stringset.Set
+		want  string
+	}{
+		{nil, ""},
+		{stringset.New(""), ""},
+		{stringset.New("single"), "single"},
+		{stringset.New("a", "b"), ""},
+		{stringset.New("", "abc"), ""},
+		{stringset.New("abc", "abd", "ab"), "ab"},
+		{stringset.New("foo.bar.baz", "foo.bar.quux", "foo.bar"), "foo.bar"},
+		{stringset.New("foo.bar.baz", "foo.bar.quux", "foo.baz"), "foo.ba"},
+		{stringset.New("/usr/lib", "/usr/local", "/var"), "/"},
+		{stringset.New("naïve", "naïf"), "naï"},
+		{stringset.New("café", "cafè"), "caf"}, // é and è share a leading byte
+	}
+	for _, test := range tests {
+		if got := test.input.CommonPrefix(); got != test.want {
+			t.Errorf("%v.CommonPrefix(): got %q, want %q", test.input, got, test.want)
+		}
+	}
+}
+
+func TestContainsPrefixOf(t *testing.T) {
+	acl := stringset.New("/home", "/home/alice", "/home/alice/src", "/var/log")
+	tests := []struct {
+		input  stringset.Set
+		p      string
+		want   string
+		wantOK bool
+	}{
+		{nil, "/home", "", false},
+		{acl, "", "", false},
+		{acl, "/home", "/home", true},                             // exact match
+		{acl, "/home/bob/x", "/home", true},                       // only the shortest matches
+		{acl, "/home/alice/src/main.go", "/home/alice/src", true}, // longest wins
+		{acl, "/home/alice/doc", "/home/alice", true},
+		{acl, "/var", "", false},
+		{acl, "/usr/bin", "", false},
+		{stringset.New("", "/a"), "/b", "", true}, // "" is a prefix of anything
+		{stringset.New("", "/a"), "/a/b", "/a", true},
+	}
+	for _, test := range tests {
+		got, ok := test.input.ContainsPrefixOf(test.p)
+		if got != test.want || ok != test.wantOK {
+			t.Errorf("%v.ContainsPrefixOf(%q): got %q, %v; want %q, %v",
+				test.input, test.p, got, ok, test.want, test.wantOK)
+		}
+	}
+}
+
+func TestHasElementWithPrefix(t *testing.T) {
+	acl := stringset.New("/home/alice", "/var/log")
+	tests := []struct {
+		input  stringset.Set
+		prefix string
+		want   bool
+	}{
+		{nil, "", false},
+		{stringset.New(""), "", true},
+		{acl, "", true},
+		{acl, "/home", true},
+		{acl, "/home/alice", true},
+		{acl, "/home/alice/", false},
+		{acl, "/var/lo", true},
+		{acl, "/usr", false},
+	}
+	for _, test := range tests {
+		if got := test.input.HasElementWithPrefix(test.prefix); got != test.want {
+			t.Errorf("%v.HasElementWithPrefix(%q): got %v, want %v", test.input, test.prefix, got, test.want)
+		}
+	}
+}
+
+func TestIsClosedUnder(t *testing.T) {
+	parent := func(s string) string {
+		if i := strings.LastIndex(s, "/"); i > 0 {
+			return s[:i]
+		}
+		return s
+	}
+	tests := []struct {
+		input stringset.Set
+		f     func(string) string
+		want  bool
+	}{
+		{nil, parent, true},
+		{stringset.New("a", "a/b", "a/b/c"), parent, true},
+		{stringset.New("a", "a/b", "x", "x/y"), parent, true},
+		{stringset.New("a", "a/b/c"), parent, false},
+		{stringset.New("a/b"), parent, false},
+		{stringset.New(testValues[:]...), strings.ToLower, true},
+		{stringset.New(testValues[:]...), strings.ToUpper, false},
+	}
+	for _, test := range tests {
+		if got := test.input.IsClosedUnder(test.f); got != test.want {
+			t.Errorf("%v.IsClosedUnder(f): got %v, want %v", test.input, got, test.want)
+		}
+	}
+}
+
+func TestCompact(t *testing.T) {
+	var s stringset.Set
+	if s.Compact(100) {
+		t.Error("Compact of nil: got true, want false")
+	}
+	if s != nil {
+		t.Errorf("Compact of nil: got %v, want nil", s)
+	}
+
+	s = stringset.New()
+	if !s.Compact(0) {
+		t.Error("Compact of empty: got false, want true")
+	}
+	if s != nil {
+		t.Errorf("Compact of empty: got %#v, want nil", s)
+	}
+
+	// A set that has not shrunk enough relative to its peak is unchanged.
+	for _, peak := range []int{-1, 0, 5, 19} {
+		s = testSet(0, 1, 2, 3, 4)
+		alias := s
+		if s.Compact(peak) {
+			t.Errorf("Compact(%d) of %v: got true, want false", peak, s)
+		}
+		s.Add(testValues[9])
+		if !alias.Contains(testValues[9]) {
+			t.Errorf("Compact(%d) allocated a new set", peak)
+		}
+	}
+
+	s = testSet(0, 1, 2, 3, 4)
+	alias := s
+	if !s.Compact(20) {
+		t.Errorf("Compact(20) of %v: got false, want true", s)
+	}
+	if want := testSet(0, 1, 2, 3, 4); !s.Equals(want) {
+		t.Errorf("Compact: got %v, want %v", s, want)
+	}
+	s.Add(testValues[9])
+	if alias.Contains(testValues[9]) {
+		t.Error("Compact did not allocate a new set")
+	}
+}
+
+// shrunkSet returns a set that has held n elements, all but keep of which
+// have been removed.
+func shrunkSet(n, keep int) stringset.Set {
+	s := benchSet(n)
+	for k := range s {
+		if s.Len() <= keep {
+			break
+		}
+		delete(s, k)
+	}
+	return s
+}
+
+func heapAlloc() uint64 {
+	var ms runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&ms)
+	return ms.HeapAlloc
+}
+
+func TestCompactShrunk(t *testing.T) {
+	const n, keep = 1 << 12, 50
+
+	// A set well below its peak is copied into a fresh map.
+	s := shrunkSet(n, keep)
+	alias, want := s, s.Clone()
+	if !s.Compact(n) {
+		t.Errorf("Compact(%d) of %d elements: got false, want true", n, keep)
+	}
+	if !s.Equals(want) {
+		t.Errorf("Compact: got %v, want %v", s, want)
+	}
+	s.Discard(want.Elements()[0])
+	if !alias.Equals(want) {
+		t.Error("Compact modified the original set")
+	}
+
+	// A set not far enough below its peak is left alone.
+	s = shrunkSet(n, keep)
+	alias = s
+	if s.Compact(keep*4 - 1) {
+		t.Errorf("Compact(%d) of %d elements: got true, want false", keep*4-1, keep)
+	}
+	s.Discard(s.Elements()[0])
+	if alias.Len() != keep-1 {
+		t.Error("Compact allocated a new set")
+	}
+}
+
+func BenchmarkCompact(b *testing.B) {
+	var saved int64
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		s := shrunkSet(1<<16, 50)
+		before := heapAlloc()
+		b.StartTimer()
+		s.Compact(1 << 16)
+		b.StopTimer()
+		saved += int64(before) - int64(heapAlloc())
+		runtime.KeepAlive(s)
+		b.StartTimer()
+	}
+	b.ReportMetric(float64(saved)/float64(b.N), "bytes-freed/op")
+}
+
+func TestQuotient(t *testing.T) {
+	tests := []struct {
+		input stringset.Set
+		want  map[string]stringset.Set
+	}{
+		{nil, nil},
+		{stringset.New("a"), map[string]stringset.Set{"a": stringset.New("a")}},
+		{stringset.New("A"), map[string]stringset.Set{"a": stringset.New("A")}},
+		{stringset.New("Go", "go", "GO", "Rust", "rust", "c"), map[string]stringset.Set{
+			"go":   stringset.New("Go", "go", "GO"),
+			"rust": stringset.New("Rust", "rust"),
+			"c":    stringset.New("c"),
+		}},
+	}
+	for _, test := range tests {
+		got := test.input.Quotient(strings.ToLower)
+		if len(got) != len(test.want) {
+			t.Errorf("%v.Quotient(lower): got %v, want %v", test.input, got, test.want)
+			continue
+		}
+		for r, c := range test.want {
+			if !got[r].Equals(c) {
+				t.Errorf("%v.Quotient(lower)[%q]: got %v, want %v", test.input, r, got[r], c)
+			}
+		}
+	}
+}
+
+// scanNUL is a bufio.SplitFunc for NUL-terminated tokens.
+func scanNUL(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	} else if atEOF && len(data) != 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+func TestFromTokens(t *testing.T) {
+	long := strings.Repeat("x", 100000) // longer than the bufio default
+	tests := []struct {
+		input string
+		split bufio.SplitFunc
+		max   int
+		want  stringset.Set
+	}{
+		{"", nil, 0, nil},
+		{"  \n\t ", nil, 0, nil},
+		{"a b  c\na\tb", nil, 0, stringset.New("a", "b", "c")},
+		{"a b\nc d\n", bufio.ScanLines, 0, stringset.New("a b", "c d")},
+		{"a b\x00c\x00\x00a b", scanNUL, 0, stringset.New("a b", "c", "")},
+		{"short " + long, nil, 1 << 20, stringset.New("short", long)},
+	}
+	for _, test := range tests {
+		got, err := stringset.FromTokens(strings.NewReader(test.input), test.split, test.max)
+		if err != nil {
+			t.Errorf("FromTokens(%.20q, %d): unexpected error: %v", test.input, test.max, err)
+		} else if !got.Equals(test.want) {
+			t.Errorf("FromTokens(%.20q, %d): got %v, want %v", test.input, test.max, got, test.want)
+		}
+	}
+}
+
+func TestFromTokensTooLong(t *testing.T) {
+	tests := []struct {
+		max, size int
+	}{
+		{1000, 2000},
+		{0, bufio.MaxScanTokenSize + 1}, // the default limit
+		{-1, bufio.MaxScanTokenSize + 1},
+	}
+	for _, test := range tests {
+		input := "a b " + strings.Repeat("x", test.size) + " c"
+		got, err := stringset.FromTokens(strings.NewReader(input), nil, test.max)
+		if !errors.Is(err, bufio.ErrTooLong) {
+			t.Errorf("FromTokens(%d bytes, %d): got error %v, want %v", test.size, test.max, err, bufio.ErrTooLong)
+		}
+		if want := stringset.New("a", "b"); !got.Equals(want) {
+			t.Errorf("FromTokens(%d bytes, %d): got partial result %v, want %v", test.size, test.max, got, want)
+		}
+	}
+}
+
+func TestFormatDiff(t *testing.T) {
+	tests := []struct {
+		before, after stringset.Set
+		want          string
+	}{
+		{nil, nil, ""},
+		{testSet(0, 1), testSet(1, 0), ""},
+		{nil, stringset.New("b", "a"), "+a\n+b\n"},
+		{stringset.New("b", "a"), nil, "-a\n-b\n"},
+		{
+			stringset.New("api", "db", "cache", "web"),
+			stringset.New("api", "cache", "queue", "auth"),
+			"+auth\n-db\n+queue\n-web\n",
+		},
+	}
+	for _, test := range tests {
+		got := stringset.FormatDiff(test.before, test.after)
+		if got != test.want {
+			t.Errorf("FormatDiff(%v, %v):\n got %q\nwant %q", test.before, test.after, got, test.want)
+		}
+
+		var buf bytes.Buffer
+		if err := stringset.WriteDiff(&buf, test.before, test.after); err != nil {
+			t.Errorf("WriteDiff(%v, %v): unexpected error: %v", test.before, test.after, err)
+		} else if buf.String() != got {
+			t.Errorf("WriteDiff(%v, %v): got %q, want %q", test.before, test.after, buf.String(), got)
+		}
+
+		// Applying the listed changes to before should yield after.
+		s := test.before.Clone()
+		for _, line := range strings.Split(strings.TrimSuffix(got, "\n"), "\n") {
+			if strings.HasPrefix(line, "+") {
+				s.Add(line[1:])
+			} else if strings.HasPrefix(line, "-") {
+				s.Discard(line[1:])
+			}
+		}
+		if !s.Equals(test.after) {
+			t.Errorf("Applying FormatDiff(%v, %v): got %v, want %v", test.before, test.after, s, test.after)
+		}
+	}
+}
+
+func TestRange(t *testing.T) {
+	nat := stringset.New(testValues[:]...)
+	tests := []struct {
+		input  stringset.Set
+		lo, hi string
+		want   stringset.Set
+	}{
+		{nil, "a", "z", nil},
+		{nat, "", "\xff", nat},
+		{nat, testValues[2], testValues[5], testSet(2, 3, 4, 5)}, // inclusive bounds
+		{nat, testValues[4], testValues[4], testSet(4)},
+		{nat, "f", "o", testSet(1, 2, 3)},
+		{nat, "u", "z", nil},                     // empty range within bounds
+		{nat, testValues[5], testValues[2], nil}, // hi < lo
+		{nat, "one", "one ", testSet(4)},
+	}
+	for _, test := range tests {
+		got := test.input.Range(test.lo, test.hi)
+		if !got.Equals(test.want) {
+			t.Errorf("%v.Range(%q, %q): got %v, want %v", test.input, test.lo, test.hi, got, test.want)
+		}
+	}
+}
+
+func TestCanonicalKey(t *testing.T) {
+	tests := []struct {
+		input stringset.Set
+		want  string
+	}{
+		{nil, ""},
+		{stringset.New(), ""},
+		{stringset.New(""), "0:"},
+		{stringset.New("a", "b"), "1:a1:b"},
+		{stringset.New("a,b"), "3:a,b"},
+		{stringset.New("b,c", "a"), "1:a3:b,c"},
+	}
+	for _, test := range tests {
+		if got := test.input.CanonicalKey(); got != test.want {
+			t.Errorf("%v.CanonicalKey(): got %q, want %q", test.input, got, test.want)
+		}
+	}
+
+	// Equal sets have equal keys, and unequal sets have unequal keys.  Draw
+	// elements from a small alphabet including separator-like characters so
+	// that collisions in a naive encoding would be likely.
+	const alphabet = "ab,:1 "
+	rng := rand.New(rand.NewSource(1))
+	randomSet := func() stringset.Set {
+		var s stringset.Set
+		for n := rng.Intn(4); n >= 0; n-- {
+			b := make([]byte, rng.Intn(4))
+			for i := range b {
+				b[i] = alphabet[rng.Intn(len(alphabet))]
+			}
+			s.Add(string(b))
+		}
+		return s
+	}
+	for i := 0; i < 20000; i++ {
+		a, b := randomSet(), randomSet()
+		if got, want := a.CanonicalKey() == b.CanonicalKey(), a.Equals(b); got != want {
+			t.Fatalf("Keys %q and %q: equal is %v, but %v.Equals(%v) is %v",
+				a.CanonicalKey(), b.CanonicalKey(), got, a, b, want)
+		}
+	}
+}
+
+func TestSinceGone(t *testing.T) {
+	tests := []struct {
+		s, baseline stringset.Set
+		since, gone stringset.Set
+	}{
+		{nil, nil, nil, nil},
+		{testSet(0, 1), nil, testSet(0, 1), nil},
+		{nil, testSet(0, 1), nil, testSet(0, 1)},
+		{testSet(0, 1, 2), testSet(0, 1, 2), nil, nil},
+		{testSet(0, 1, 2, 3), testSet(2, 3, 4, 5), testSet(0, 1), testSet(4, 5)},
+	}
+	for _, test := range tests {
+		since := test.s.Since(test.baseline)
+		if !since.Equals(test.since) || !since.Equals(test.s.Diff(test.baseline)) {
+			t.Errorf("%v.Since(%v): got %v, want %v", test.s, test.baseline, since, test.since)
+		}
+		gone := test.s.Gone(test.baseline)
+		if !gone.Equals(test.gone) || !gone.Equals(test.baseline.Diff(test.s)) {
+			t.Errorf("%v.Gone(%v): got %v, want %v", test.s, test.baseline, gone, test.gone)
+		}
+	}
+}
+
+func TestParallelUnion(t *testing.T) {
+	chunks := func(nchunks, size int) [][]string {
+		var out [][]string
+		for i := 0; i < nchunks; i++ {
+			var chunk []string
+			for j := 0; j < size; j++ {
+				chunk = append(chunk, strconv.Itoa((i*size/2+j)%1000))
+			}
+			out = append(out, chunk)
+		}
+		return out
+	}
+	tests := []struct {
+		n      int
+		inputs [][]string
+	}{
+		{1, nil},
+		{4, [][]string{nil, {}}},
+		{0, [][]string{testKeys(0, 1), testKeys(1, 2)}},
+		{3, [][]string{testKeys(0, 1), testKeys(1, 2)}},
+		{4, chunks(50, 100)},
+		{16, chunks(7, 300)},
+	}
+	for _, test := range tests {
+		var want stringset.Set
+		for _, in := range test.inputs {
+			for _, s := range in {
+				want.Add(s)
+			}
+		}
+		got := stringset.ParallelUnion(test.n, test.inputs)
+		if !got.Equals(want) {
+			t.Errorf("ParallelUnion(%d, ...): got %d elements, want %d", test.n, got.Len(), want.Len())
+		}
+		if want.Empty() && got != nil {
+			t.Errorf("ParallelUnion(%d, ...): got %#v, want nil", test.n, got)
+		}
+	}
+}
+
+func benchChunks() [][]string {
+	var out [][]string
+	for i := 0; i < 64; i++ {
+		chunk := make([]string, 4096)
+		for j := range chunk {
+			chunk[j] = strconv.Itoa(i*3000 + j)
+		}
+		out = append(out, chunk)
+	}
+	return out
+}
+
+func BenchmarkParallelUnion(b *testing.B) {
+	inputs := benchChunks()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = stringset.ParallelUnion(runtime.GOMAXPROCS(0), inputs)
+	}
+}
+
+func BenchmarkSerialUnion(b *testing.B) {
+	inputs := benchChunks()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var s stringset.Set
+		for _, in := range inputs {
+			s.Add(in...)
+		}
+	}
+}
+
+func TestFold(t *testing.T) {
+	tests := []struct {
+		input      stringset.Set
+		want       stringset.Set
+		collisions map[string][]string
+	}{
+		{nil, nil, nil},
+		{stringset.New("a", "b"), stringset.New("a", "b"), nil},
+		{stringset.New("Foo", "bar"), stringset.New("foo", "bar"), nil},
+		{stringset.New("Foo", "foo", "FOO", "Bar", "baz"), stringset.New("foo", "bar", "baz"),
+			map[string][]string{"foo": {"FOO", "Foo", "foo"}}},
+		{stringset.New("ab", "AB", "cd", "Cd", "e"), stringset.New("ab", "cd", "e"),
+			map[string][]string{"ab": {"AB", "ab"}, "cd": {"Cd", "cd"}}},
+		{stringset.New("Σ", "σ", "ς", "K", "k", "\u212a"), stringset.New("σ", "k"), // U+212A is KELVIN SIGN
+			map[string][]string{"σ": {"Σ", "ς", "σ"}, "k": {"K", "k", "\u212a"}}},
+		{stringset.New("Éclair", "éclair", "eclair"), stringset.New("éclair", "eclair"),
+			map[string][]string{"éclair": {"Éclair", "éclair"}}},
+	}
+	for _, test := range tests {
+		got, coll := test.input.Fold()
+		if !got.Equals(test.want) {
+			t.Errorf("%v.Fold(): got %v, want %v", test.input, got, test.want)
+		}
+		if !reflect.DeepEqual(coll, test.collisions) {
+			t.Errorf("%v.Fold() collisions: got %q, want %q", test.input, coll, test.collisions)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	in := stringset.New("a.go", "b.go", "c.py", "README")
+	got, coll := in.Normalize(filepath.Ext)
+	if want := stringset.New(".go", ".py", ""); !got.Equals(want) {
+		t.Errorf("Normalize(Ext): got %v, want %v", got, want)
+	}
+	if want := map[string][]string{".go": {"a.go", "b.go"}}; !reflect.DeepEqual(coll, want) {
+		t.Errorf("Normalize(Ext) collisions: got %q, want %q", coll, want)
+	}
+}
+
+func TestWouldAddRemove(t *testing.T) {
+	for _, s := range []stringset.Set{nil, stringset.New(), testSet(0, 2, 4)} {
+		for _, v := range testValues {
+			wouldAdd, wouldRemove := s.WouldAdd(v), s.WouldRemove(v)
+			if wouldAdd == wouldRemove {
+				t.Errorf("%v: WouldAdd(%q) = WouldRemove(%q) = %v", s, v, v, wouldAdd)
+			}
+
+			c := s.Clone()
+			if got := c.Add(v); got != wouldAdd {
+				t.Errorf("%v.WouldAdd(%q): got %v, but Add reported %v", s, v, wouldAdd, got)
+			}
+			c = s.Clone()
+			if got := c.Discard(v); got != wouldRemove {
+				t.Errorf("%v.WouldRemove(%q): got %v, but Discard reported %v", s, v, wouldRemove, got)
+			}
+		}
+	}
+}
+
+func TestMaxMinN(t *testing.T) {
+	byLength := func(a, b string) bool {
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return a < b
+	}
+	nat := stringset.New(testValues[:]...)
+	tests := []struct {
+		input    stringset.Set
+		n        int
+		less     func(a, b string) bool
+		max, min []string
+	}{
+		{nil, 3, nil, nil, nil},
+		{nat, 0, nil, nil, nil},
+		{nat, -1, nil, nil, nil},
+		{nat, 1, nil, testKeys(9), testKeys(0)},
+		{nat, 3, nil, testKeys(9, 8, 7), testKeys(0, 1, 2)},
+		{nat, 3, byLength, []string{"three", "seven", "eight"}, []string{"one", "six", "ten"}},
+		{testSet(4, 1), 5, nil, testKeys(4, 1), testKeys(1, 4)},
+	}
+	for _, test := range tests {
+		if got := test.input.MaxN(test.n, test.less); !reflect.DeepEqual(got, test.max) {
+			t.Errorf("%v.MaxN(%d): got %+q, want %+q", test.input, test.n, got, test.max)
+		}
+		if got := test.input.MinN(test.n, test.less); !reflect.DeepEqual(got, test.min) {
+			t.Errorf("%v.MinN(%d): got %+q, want %+q", test.input, test.n, got, test.min)
+		}
+	}
+
+	// Compare against sorting the whole set, for random inputs.
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		s := stringset.New()
+		for j := rng.Intn(200); j >= 0; j-- {
+			s.Add(strconv.Itoa(rng.Intn(1000)))
+		}
+		all := s.Elements()
+		n := rng.Intn(len(all) + 5)
+		want := all
+		if n < len(all) {
+			want = all[:n]
+		}
+		if n == 0 {
+			want = nil
+		}
+		if got := s.MinN(n, nil); !reflect.DeepEqual(got, want) {
+			t.Errorf("MinN(%d) of %d: got %q, want %q", n, len(all), got, want)
+		}
+		rev := make([]string, len(all))
+		for i, elt := range all {
+			rev[len(all)-1-i] = elt
+		}
+		if n < len(rev) {
+			rev = rev[:n]
+		}
+		if n == 0 {
+			rev = nil
+		}
+		if got := s.MaxN(n, nil); !reflect.DeepEqual(got, rev) {
+			t.Errorf("MaxN(%d) of %d: got %q, want %q", n, len(all), got, rev)
+		}
+	}
+}
+
+func BenchmarkMaxN(b *testing.B) {
+	s := benchSet(1 << 20)
+	b.Run("MaxN", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = s.MaxN(10, nil)
+		}
+	})
+	b.Run("Sort", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			all := s.Elements()
+			top := make([]string, 10)
+			for j := range top {
+				top[j] = all[len(all)-1-j]
+			}
+		}
+	})
+}
+
+func TestScope(t *testing.T) {
+	keys := stringset.New(
+		"db", "db.host", "db.port", "db.pool.min", "db.pool.max",
+		"log.level", "log.sink.file", "dbx.other",
+	)
+	tests := []struct {
+		input  stringset.Set
+		prefix string
+		want   stringset.Set
+	}{
+		{nil, "db.", nil},
+		{keys, "nonesuch.", nil},
+		{keys, "", keys},
+		{keys, "db.", stringset.New("host", "port", "pool.min", "pool.max")},
+		{keys, "db.pool.", stringset.New("min", "max")},
+		{keys, "log.sink.", stringset.New("file")},
+		{keys, "db", stringset.New("", ".host", ".port", ".pool.min", ".pool.max", "x.other")},
+		{keys.Scope("db."), "pool.", stringset.New("min", "max")},
+	}
+	for _, test := range tests {
+		got := test.input.Scope(test.prefix)
+		if !got.Equals(test.want) {
+			t.Errorf("%v.Scope(%q): got %v, want %v", test.input, test.prefix, got, test.want)
+		}
+		if test.want.Empty() && got != nil {
+			t.Errorf("%v.Scope(%q): got %#v, want nil", test.input, test.prefix, got)
+		}
+	}
+}
+
+func TestIntersectEach(t *testing.T) {
+	nat := stringset.New(testValues[:]...)
+	tests := []struct {
+		left, right stringset.Set
+	}{
+		{nil, nil},
+		{nat, nil},
+		{nil, nat},
+		{nat, nat},
+		{testSet(0, 1, 2, 3), testSet(2, 3, 4)},
+		{testSet(2, 3, 4), testSet(0, 1, 2, 3, 5, 6, 7)},
+		{testSet(0, 1), testSet(2, 3)},
+	}
+	for _, test := range tests {
+		want := test.left.Intersect(test.right)
+
+		var seen []string
+		test.left.IntersectEach(test.right, func(elt string) { seen = append(seen, elt) })
+		if len(seen) != want.Len() || !stringset.New(seen...).Equals(want) {
+			t.Errorf("%v.IntersectEach(%v): visited %q, want %v", test.left, test.right, seen, want)
+		}
+
+		// Stopping after the first element visits exactly one element.
+		n := 0
+		done := test.left.IntersectEachWhile(test.right, func(string) bool { n++; return false })
+		if want.Empty() {
+			if n != 0 || !done {
+				t.Errorf("%v.IntersectEachWhile(%v): visited %d, done=%v; want 0, true", test.left, test.right, n, done)
+			}
+		} else if n != 1 || done {
+			t.Errorf("%v.IntersectEachWhile(%v): visited %d, done=%v; want 1, false", test.left, test.right, n, done)
+		}
+	}
+}
+
+func TestDiffUnionEach(t *testing.T) {
+	nat := stringset.New(testValues[:]...)
+	tests := []struct {
+		left, right stringset.Set
+	}{
+		{nil, nil},
+		{nat, nil},
+		{nil, nat},
+		{nat, nat},
+		{testSet(0, 1, 2, 3), testSet(2, 3, 4)},
+		{testSet(2, 3, 4), testSet(0, 1, 2, 3, 5, 6, 7)},
+		{testSet(0, 1), testSet(2, 3)},
+	}
+	for _, test := range tests {
+		for _, op := range []struct {
+			name string
+			each func(stringset.Set, func(string))
+			all  func(stringset.Set, func(string) bool) bool
+			want stringset.Set
+		}{
+			{"DiffEach", test.left.DiffEach, test.left.DiffEachWhile, test.left.Diff(test.right)},
+			{"UnionEach", test.left.UnionEach, test.left.UnionEachWhile, test.left.Union(test.right)},
+		} {
+			// Each element is visited exactly once.
+			count := make(map[string]int)
+			op.each(test.right, func(elt string) { count[elt]++ })
+			for elt, n := range count {
+				if n != 1 {
+					t.Errorf("%v.%s(%v): visited %q %d times, want 1", test.left, op.name, test.right, elt, n)
+				}
+			}
+			if got := stringset.FromKeys(count); !got.Equals(op.want) {
+				t.Errorf("%v.%s(%v): visited %v, want %v", test.left, op.name, test.right, got, op.want)
+			}
+
+			// Stopping after the first element visits exactly one element.
+			n := 0
+			done := op.all(test.right, func(string) bool { n++; return false })
+			if op.want.Empty() {
+				if n != 0 || !done {
+					t.Errorf("%v.%sWhile(%v): visited %d, done=%v; want 0, true", test.left, op.name, test.right, n, done)
+				}
+			} else if n != 1 || done {
+				t.Errorf("%v.%sWhile(%v): visited %d, done=%v; want 1, false", test.left, op.name, test.right, n, done)
+			}
+		}
+	}
+}
+
+func TestIntersectEachAllocs(t *testing.T) {
+	a, b := benchSet(1000), benchSet(500)
+	n := 0
+	count := func(string) { n++ }
+	allocs := testing.AllocsPerRun(10, func() { a.IntersectEach(b, count) })
+	if allocs != 0 {
+		t.Errorf("IntersectEach: got %v allocations, want 0", allocs)
+	}
+	// AllocsPerRun makes one warm-up call before the measured runs.
+	if want := 11 * 500; n != want {
+		t.Errorf("IntersectEach: visited %d elements, want %d", n, want)
+	}
+}
+
+func TestCheckSchema(t *testing.T) {
+	required := stringset.New("host", "port")
+	optional := stringset.New("timeout", "retries")
+	tests := []struct {
+		input              stringset.Set
+		required, optional stringset.Set
+		want               string // "" means no error
+	}{
+		{nil, nil, nil, ""},
+		{stringset.New("host", "port"), required, optional, ""},
+		{stringset.New("host", "port", "retries"), required, optional, ""},
+		{stringset.New("host", "port"), required, nil, ""},
+		{nil, nil, optional, ""},
+		{stringset.New("host"), required, optional,
+			`missing required keys "port"`},
+		{nil, required, optional,
+			`missing required keys "host", "port"`},
+		{stringset.New("host", "port", "verbose", "color"), required, optional,
+			`unexpected keys "color", "verbose"`},
+		{stringset.New("port", "timeout", "user"), required, optional,
+			`missing required keys "host"; unexpected keys "user"`},
+		{stringset.New("port"), required, required,
+			`missing required keys "host"`},
+	}
+	for _, test := range tests {
+		err := test.input.CheckSchema(test.required, test.optional)
+		if test.want == "" {
+			if err != nil {
+				t.Errorf("%v.CheckSchema(%v, %v): unexpected error: %v", test.input, test.required, test.optional, err)
+			}
+		} else if err == nil || err.Error() != test.want {
+			t.Errorf("%v.CheckSchema(%v, %v): got error %v, want %q", test.input, test.required, test.optional, err, test.want)
+		}
+	}
+}
+
+func TestMinimalPrefixes(t *testing.T) {
+	tests := []struct {
+		input, want stringset.Set
+	}{
+		{nil, nil},
+		{stringset.New("a"), stringset.New("a")},
+		{stringset.New("a/", "a/b/", "a/c", "b/x", "b/y"), stringset.New("a/", "b/x", "b/y")},
+		{stringset.New("a/b/c/", "a/b/", "a/", "a/b/d"), stringset.New("a/")},
+		{stringset.New("usr/lib/", "usr/local/", "usr/local/bin/", "var/"),
+			stringset.New("usr/lib/", "usr/local/", "var/")},
+		{stringset.New("ab", "ac", "abc", "acd"), stringset.New("ab", "ac")},
+		{stringset.New("a", "a-b", "a.b", "ab"), stringset.New("a")},
+		{stringset.New("", "x", "y"), stringset.New("")},
+		{testSet(0, 1, 2, 3), testSet(0, 1, 2, 3)},
+	}
+	for _, test := range tests {
+		if got := test.input.MinimalPrefixes(); !got.Equals(test.want) {
+			t.Errorf("%v.MinimalPrefixes(): got %v, want %v", test.input, got, test.want)
+		}
+	}
+}
+
+func TestAddTracked(t *testing.T) {
+	type step struct {
+		elt            string
+		newMin, newMax bool
+	}
+	tests := []struct {
+		desc  string
+		steps []step
+	}{
+		{"ascending", []step{
+			{"a", true, true}, {"b", false, true}, {"c", false, true}, {"d", false, true},
+		}},
+		{"descending", []step{
+			{"d", true, true}, {"c", true, false}, {"b", true, false}, {"a", true, false},
+		}},
+		{"mixed", []step{
+			{"m", true, true}, {"c", true, false}, {"x", false, true}, {"p", false, false},
+			{"a", true, false}, {"z", false, true}, {"n", false, false},
+		}},
+		{"duplicates", []step{
+			{"m", true, true}, {"m", false, false}, {"a", true, false}, {"a", false, false},
+			{"", true, false}, {"", false, false},
+		}},
+	}
+	for _, test := range tests {
+		var s stringset.Set
+		var want stringset.Set
+		for _, st := range test.steps {
+			newMin, newMax := s.AddTracked(st.elt)
+			if newMin != st.newMin || newMax != st.newMax {
+				t.Errorf("%s: AddTracked(%q): got (%v, %v), want (%v, %v)",
+					test.desc, st.elt, newMin, newMax, st.newMin, st.newMax)
+			}
+			want.Add(st.elt)
+			if !s.Equals(want) {
+				t.Errorf("%s: after AddTracked(%q): got %v, want %v", test.desc, st.elt, s, want)
+			}
+		}
+	}
+}
+
+func TestPrefixCount(t *testing.T) {
+	keys := stringset.New(
+		"db.host", "db.port", "db.pool.min", "db.pool.max", "db.pool.idle.max",
+		"log.level", "log.sink.file", "log.sink.syslog", "trace",
+	)
+	tests := []struct {
+		input stringset.Set
+		sep   string
+		depth int
+		want  map[string]int
+	}{
+		{nil, ".", 1, nil},
+		{keys, ".", 0, nil},
+		{keys, ".", -1, nil},
+		{keys, ".", 1, map[string]int{"db": 5, "log": 3, "trace": 1}},
+		{keys, ".", 2, map[string]int{
+			"db.host": 1, "db.port": 1, "db.pool": 3,
+			"log.level": 1, "log.sink": 2, "trace": 1,
+		}},
+		{keys, ".", 3, map[string]int{
+			"db.host": 1, "db.port": 1, "db.pool.min": 1, "db.pool.max": 1, "db.pool.idle": 1,
+			"log.level": 1, "log.sink.file": 1, "log.sink.syslog": 1, "trace": 1,
+		}},
+		{keys, "/", 1, map[string]int{
+			"db.host": 1, "db.port": 1, "db.pool.min": 1, "db.pool.max": 1, "db.pool.idle.max": 1,
+			"log.level": 1, "log.sink.file": 1, "log.sink.syslog": 1, "trace": 1,
+		}},
+		{stringset.New("a::b", "a::c", "b"), "::", 1, map[string]int{"a": 2, "b": 1}},
+	}
+	for _, test := range tests {
+		if got := test.input.PrefixCount(test.sep, test.depth); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v.PrefixCount(%q, %d): got %v, want %v", test.input, test.sep, test.depth, got, test.want)
+		}
+	}
+}
+
+func TestSliceAlgebra(t *testing.T) {
+	check := func(s stringset.Set, elts []string) {
+		t.Helper()
+		other := stringset.New(elts...)
+		for _, test := range []struct {
+			name      string
+			got, want stringset.Set
+		}{
+			{"UnionSlice", s.UnionSlice(elts), s.Union(other)},
+			{"IntersectSlice", s.IntersectSlice(elts), s.Intersect(other)},
+			{"DiffSlice", s.DiffSlice(elts), s.Diff(other)},
+		} {
+			if !test.got.Equals(test.want) {
+				t.Errorf("%v.%s(%q): got %v, want %v", s, test.name, elts, test.got, test.want)
+			}
+			if test.want == nil && test.got != nil {
+				t.Errorf("%v.%s(%q): got %#v, want nil", s, test.name, elts, test.got)
+			}
+		}
+	}
+	check(nil, nil)
+	check(nil, testKeys(0, 1))
+	check(testSet(0, 1), nil)
+	check(testSet(0, 1), []string{})
+	check(testSet(0, 1, 2), testKeys(1, 1, 1))
+	check(testSet(0, 1, 2), testKeys(0, 1, 2, 2, 1, 0))
+	check(testSet(0, 1, 2), testKeys(3, 4, 5, 6, 7, 8, 9, 0, 0, 3))
+
+	// Compare against the Set-based operations for random inputs, with slices
+	// both shorter and longer than the set.
+	rng := rand.New(rand.NewSource(1))
+	randKeys := func(n int) []string {
+		var keys []string
+		for i := 0; i < n; i++ {
+			keys = append(keys, testValues[rng.Intn(len(testValues))])
+		}
+		return keys
+	}
+	for i := 0; i < 500; i++ {
+		var s stringset.Set
+		s.Add(randKeys(rng.Intn(12))...)
+		if s.Empty() && rng.Intn(2) == 0 {
+			s = nil
+		}
+		check(s, randKeys(rng.Intn(25)))
+	}
+}
+
+func BenchmarkSliceAlgebra(b *testing.B) {
+	ops := []struct {
+		name  string
+		slice func(stringset.Set, []string) stringset.Set
+		set   func(stringset.Set, stringset.Set) stringset.Set
+	}{
+		{"Union", stringset.Set.UnionSlice, stringset.Set.Union},
+		{"Intersect", stringset.Set.IntersectSlice, stringset.Set.Intersect},
+		{"Diff", stringset.Set.DiffSlice, stringset.Set.Diff},
+	}
+	// Each slice overlaps the set in up to half its elements.
+	for _, size := range []struct{ set, slice int }{
+		{1000, 1}, {1000, 8}, {1000, 100}, {1000, 1000}, {10, 1000},
+	} {
+		s := benchSet(size.set)
+		var elts []string
+		for i := 0; i < size.slice; i++ {
+			elts = append(elts, strconv.Itoa(size.set-size.slice/2+i))
+		}
+		for _, op := range ops {
+			b.Run(fmt.Sprintf("%sSlice/%d-%d", op.name, size.set, size.slice), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					_ = op.slice(s, elts)
+				}
+			})
+			b.Run(fmt.Sprintf("%sSet/%d-%d", op.name, size.set, size.slice), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					_ = op.set(s, stringset.New(elts...))
+				}
+			})
+		}
+	}
+}
+
+func TestNGrams(t *testing.T) {
+	tests := []struct {
+		input stringset.Set
+		n     int
+		want  stringset.Set
+	}{
+		{nil, 2, nil},
+		{stringset.New("abc"), 0, nil},
+		{stringset.New("abc"), -1, nil},
+		{stringset.New("abc"), 4, nil},
+		{stringset.New("", "a"), 1, stringset.New("a")},
+		{stringset.New("abc"), 1, stringset.New("a", "b", "c")},
+		{stringset.New("abc"), 2, stringset.New("ab", "bc")},
+		{stringset.New("abc"), 3, stringset.New("abc")},
+		{stringset.New("abab", "bab", "x"), 2, stringset.New("ab", "ba")},
+		{stringset.New("night", "nacht"), 3, stringset.New("nig", "igh", "ght", "nac", "ach", "cht")},
+		{stringset.New("héllo"), 2, stringset.New("hé", "él", "ll", "lo")},
+		{stringset.New("日本語", "本"), 2, stringset.New("日本", "本語")},
+		{stringset.New("日本語", "本"), 1, stringset.New("日", "本", "語")},
+		{stringset.New("日本語"), 4, nil},
+	}
+	for _, test := range tests {
+		got := test.input.NGrams(test.n)
+		if !got.Equals(test.want) {
+			t.Errorf("%v.NGrams(%d): got %v, want %v", test.input, test.n, got, test.want)
+		}
+		if test.want == nil && got != nil {
+			t.Errorf("%v.NGrams(%d): got %#v, want nil", test.input, test.n, got)
+		}
+	}
+}